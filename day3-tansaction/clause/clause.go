@@ -5,6 +5,8 @@ import "strings"
 type Clause struct {
 	sql     map[Type]string
 	sqlVars map[Type][]interface{}
+	//生成第index个绑定参数的占位符，为nil时保留?
+	Placeholder func(index int) string
 }
 
 type Type int
@@ -35,6 +37,22 @@ func (c *Clause) Set(name Type, vars ...interface{}) {
 	c.sqlVars[name] = vars
 }
 
+// 复制一份Clause，修改副本不会影响原来的Clause
+func (c Clause) Clone() Clause {
+	clone := Clause{Placeholder: c.Placeholder}
+	if c.sql != nil {
+		clone.sql = make(map[Type]string, len(c.sql))
+		clone.sqlVars = make(map[Type][]interface{}, len(c.sqlVars))
+		for k, v := range c.sql {
+			clone.sql[k] = v
+		}
+		for k, v := range c.sqlVars {
+			clone.sqlVars[k] = v
+		}
+	}
+	return clone
+}
+
 // 拼接SQL语句的函数
 func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 	var sqls []string
@@ -45,5 +63,33 @@ func (c *Clause) Build(orders ...Type) (string, []interface{}) {
 			vars = append(vars, c.sqlVars[order]...)
 		}
 	}
-	return strings.Join(sqls, " "), vars
+	return Rebind(strings.Join(sqls, " "), c.Placeholder), vars
+}
+
+// 将sql中的?依次替换为placeholder生成的占位符，引号内的?不会被替换。
+// placeholder为nil或者生成的仍是?时原样返回
+func Rebind(sql string, placeholder func(index int) string) string {
+	if placeholder == nil || placeholder(1) == "?" || !strings.Contains(sql, "?") {
+		return sql
+	}
+	var b strings.Builder
+	var quote rune
+	index := 0
+	for _, r := range sql {
+		switch {
+		case quote != 0:
+			//处于引号内部，直到遇到相同的引号为止
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			index++
+			b.WriteString(placeholder(index))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
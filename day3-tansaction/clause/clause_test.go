@@ -1,6 +1,7 @@
 package clause
 
 import (
+	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"reflect"
 	"testing"
@@ -22,8 +23,48 @@ func testSelect(t *testing.T) {
 	}
 }
 
+func testPlaceholder(t *testing.T) {
+	clause := Clause{Placeholder: func(index int) string { return fmt.Sprintf("$%d", index) }}
+	clause.Set(LIMIT, 3)
+	clause.Set(SELECT, "User", []string{"*"})
+	clause.Set(WHERE, "Name = ? AND Remark <> '?'", "Tom")
+	sql, vars := clause.Build(SELECT, WHERE, LIMIT)
+	if sql != "SELECT * FROM User WHERE Name = $1 AND Remark <> '?' LIMIT $2" {
+		t.Fatal("failed to rebind SQL, got", sql)
+	}
+	if !reflect.DeepEqual(vars, []interface{}{"Tom", 3}) {
+		t.Fatal("failed to build SQLVars")
+	}
+}
+
 func TestClause_Build(t *testing.T) {
 	t.Run("select", func(t *testing.T) {
 		testSelect(t)
 	})
+	t.Run("placeholder", func(t *testing.T) {
+		testPlaceholder(t)
+	})
+}
+
+func TestInterpolate(t *testing.T) {
+	name := "Tom"
+	tests := []struct {
+		sql  string
+		vars []interface{}
+		want string
+	}{
+		{"SELECT * FROM User WHERE Name = ? AND Age > ?", []interface{}{"O'Neil", 18},
+			"SELECT * FROM User WHERE Name = 'O''Neil' AND Age > 18"},
+		{"UPDATE User SET Avatar = $2 WHERE Name = $1 AND Remark <> '$1'", []interface{}{"Tom", []byte("ab")},
+			"UPDATE User SET Avatar = X'6162' WHERE Name = 'Tom' AND Remark <> '$1'"},
+		{"INSERT INTO User VALUES (?, ?, ?)", []interface{}{nil, true},
+			"INSERT INTO User VALUES (NULL, TRUE, ?)"},
+		{"INSERT INTO User VALUES (?, ?)", []interface{}{(*string)(nil), &name},
+			"INSERT INTO User VALUES (NULL, 'Tom')"},
+	}
+	for _, tt := range tests {
+		if got := Interpolate(tt.sql, tt.vars, nil); got != tt.want {
+			t.Fatalf("expect %s, but got %s", tt.want, got)
+		}
+	}
 }
@@ -28,7 +28,7 @@ func init() {
 func genBindVars(num int) string {
 	var vars []string
 	for i := 0; i < num; i++ {
-		vars = append(vars, " ?")
+		vars = append(vars, "?")
 
 	}
 	return strings.Join(vars, ", ")
@@ -104,5 +104,5 @@ func _delete(values ...interface{}) (string, []interface{}) {
 }
 
 func _count(values ...interface{}) (string, []interface{}) {
-	return _select(values[0], []string{"counts(*)"})
+	return _select(values[0], []string{"count(*)"})
 }
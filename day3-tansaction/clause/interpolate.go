@@ -0,0 +1,98 @@
+package clause
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 将sql中的占位符依次替换为参数的字面量，用于阅读和调试，结果不应被执行。
+// 支持?和$n两种占位符，引号内的内容不会被替换，缺少参数时保留占位符。
+// literal生成参数的字面量，通常为dialect的Literal方法，为nil时使用Literal
+func Interpolate(sql string, vars []interface{}, literal func(v interface{}) string) string {
+	if len(vars) == 0 {
+		return sql
+	}
+	if literal == nil {
+		literal = Literal
+	}
+	var b strings.Builder
+	var quote rune
+	index := 0
+	runes := []rune(sql)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?' && index < len(vars):
+			b.WriteString(literal(vars[index]))
+			index++
+			continue
+		case r == '$':
+			j := i + 1
+			for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(string(runes[i+1 : j])); err == nil && n >= 1 && n <= len(vars) {
+				b.WriteString(literal(vars[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// 返回v在SQL中的通用字面量，字符串使用单引号并转义其中的单引号，nil和nil指针为NULL。
+// 各数据库字符串转义、二进制数据写法不同的部分由dialect的Literal方法处理
+func Literal(v interface{}) string {
+	switch x := LiteralValue(v).(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(x, "'", "''") + "'"
+	case []byte:
+		return fmt.Sprintf("X'%x'", x)
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		return "'" + x.Format("2006-01-02 15:04:05.999999999") + "'"
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// LiteralValue 将v转换为写成字面量时使用的值：nil指针为nil，指针被解引用，
+// 实现了driver.Valuer或fmt.Stringer的类型分别转换为Value()和String()的结果
+func LiteralValue(v interface{}) interface{} {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	switch x := v.(type) {
+	case nil, string, []byte, bool, time.Time:
+		return v
+	case driver.Valuer:
+		if dv, err := x.Value(); err == nil {
+			return LiteralValue(dv)
+		}
+		return v
+	case fmt.Stringer:
+		return x.String()
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		return LiteralValue(rv.Elem().Interface())
+	}
+	return v
+}
@@ -1,6 +1,9 @@
 package dialect
 
-import "reflect"
+import (
+	"database/sql"
+	"reflect"
+)
 
 //实现ORM的第一步是需要将Go语言的类型映射为数据库中的类型
 //不同数据库支持的数据类型也是有差异的，即使功能相同，在 SQL 语句的表达上也可能有差异。
@@ -10,8 +13,38 @@ import "reflect"
 var dialectsMap = map[string]Dialect{}
 
 type Dialect interface {
-	DataTypeOf(typ reflect.Value) string
+	//将Go语言的类型映射为数据库的类型，size为字段声明的长度，0表示未声明
+	DataTypeOf(typ reflect.Value, size int) string
 	TableExistSQL(tableName string) (string, []interface{})
+	//为表名、列名等标识符加上引号
+	Quote(name string) string
+	//自增列的关键字
+	AutoIncrement() string
+	//自增列使用的列类型，typ为根据Go类型推断的类型
+	AutoIncrementType(typ string) string
+	//插入数据后取得自增主键的RETURNING子句，返回空字符串时使用LastInsertId
+	InsertReturning(column string) string
+	//一条语句插入rows行时，根据LastInsertId计算第一行的自增值，之后的行依次加1
+	FirstInsertID(lastInsertID int64, rows int) int64
+	//存储JSON文本的列类型
+	JSONType() string
+	//返回v在SQL中的字面量，用于DryRun时把参数内联到SQL中阅读
+	Literal(v interface{}) string
+	//第index个（从1开始）绑定参数的占位符，例如SQLite中为?，PostgreSQL中为$index
+	Placeholder(index int) string
+	//嵌套事务使用的保存点语句
+	SavepointSQL(name string) string
+	RollbackToSavepointSQL(name string) string
+	ReleaseSavepointSQL(name string) string
+	//检查数据库是否支持事务选项中的隔离级别和只读标记，不支持时返回错误
+	CheckTxOptions(opts *sql.TxOptions) error
+	//判断错误是否是可以通过重新执行整个事务解决的临时错误，例如锁冲突、序列化失败
+	IsRetryable(err error) bool
+}
+
+// 驱动中带有SQLSTATE错误码的错误，例如pgx的*pgconn.PgError和lib/pq的*pq.Error
+type sqlStateError interface {
+	SQLState() string
 }
 
 func RegisterDialect(name string, dialect Dialect) {
@@ -0,0 +1,146 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"geeorm/clause"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 增加对MySQL的支持
+type mysql struct {
+}
+
+var _ Dialect = (*mysql)(nil)
+
+// 未声明长度时varchar使用的默认长度
+const mysqlDefaultVarcharSize = 255
+
+func init() {
+	RegisterDialect("mysql", &mysql{})
+}
+
+// 将Go语言的类型映射为MySQL中的数据类型
+func (m *mysql) DataTypeOf(typ reflect.Value, size int) string {
+	typ = indirectValue(typ)
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "tinyint(1)"
+	case reflect.Int8:
+		return "tinyint"
+	case reflect.Int16:
+		return "smallint"
+	case reflect.Int32:
+		return "int"
+	case reflect.Int, reflect.Int64:
+		return "bigint"
+	case reflect.Uint8:
+		return "tinyint unsigned"
+	case reflect.Uint16:
+		return "smallint unsigned"
+	case reflect.Uint32:
+		return "int unsigned"
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return "bigint unsigned"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.String:
+		if size <= 0 {
+			size = mysqlDefaultVarcharSize
+		}
+		//varchar最多65535字节，超过时改用longtext
+		if size > 65535 {
+			return "longtext"
+		}
+		return fmt.Sprintf("varchar(%d)", size)
+	case reflect.Array, reflect.Slice:
+		return "longblob"
+	case reflect.Struct:
+		if _, ok := typ.Interface().(time.Time); ok {
+			return "datetime(6)"
+		}
+	}
+	panic(fmt.Sprintf("invalid sql type %s (%s)", typ.Type().Name(), typ.Kind()))
+}
+
+// 通过information_schema判断当前数据库中表tableName是否存在
+func (m *mysql) TableExistSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", args
+}
+
+// MySQL使用反引号包裹标识符
+func (m *mysql) Quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (m *mysql) AutoIncrement() string {
+	return "AUTO_INCREMENT"
+}
+
+func (m *mysql) AutoIncrementType(typ string) string {
+	return typ
+}
+
+func (m *mysql) InsertReturning(column string) string {
+	return ""
+}
+
+// MySQL的LastInsertId是同一条语句插入的第一行的自增值，之后的行按auto_increment_increment递增。
+// Insert按照每行加1写回自增值，因此一次插入多行时要求auto_increment_increment为默认的1，
+// 修改了该变量（例如多主复制）时应逐行插入，或者写入后重新查询主键
+func (m *mysql) FirstInsertID(lastInsertID int64, rows int) int64 {
+	return lastInsertID
+}
+
+// MySQL 5.7起支持JSON类型
+func (m *mysql) JSONType() string {
+	return "json"
+}
+
+// MySQL默认把反斜杠当作转义字符，字符串中的反斜杠需要再转义一次
+func (m *mysql) Literal(v interface{}) string {
+	if x, ok := clause.LiteralValue(v).(string); ok {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(x) + "'"
+	}
+	return clause.Literal(v)
+}
+
+func (m *mysql) Placeholder(index int) string {
+	return "?"
+}
+
+// MySQL的保存点语句
+func (m *mysql) SavepointSQL(name string) string {
+	return "SAVEPOINT " + m.Quote(name)
+}
+
+func (m *mysql) RollbackToSavepointSQL(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + m.Quote(name)
+}
+
+func (m *mysql) ReleaseSavepointSQL(name string) string {
+	return "RELEASE SAVEPOINT " + m.Quote(name)
+}
+
+// MySQL支持四种标准隔离级别和只读事务
+func (m *mysql) CheckTxOptions(opts *sql.TxOptions) error {
+	switch opts.Isolation {
+	case sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable:
+		return nil
+	}
+	return fmt.Errorf("mysql does not support isolation level %s", opts.Isolation)
+}
+
+// 1213为死锁，1205为等待锁超时，两者都可以通过重新执行事务解决
+func (m *mysql) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1213") || strings.Contains(msg, "Error 1205")
+}
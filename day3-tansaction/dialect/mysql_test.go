@@ -0,0 +1,54 @@
+package dialect
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMysql_DataTypeOf(t *testing.T) {
+	dial := &mysql{}
+	cases := []struct {
+		Value interface{}
+		Size  int
+		Type  string
+	}{
+		{"Tom", 0, "varchar(255)"},
+		{"Tom", 64, "varchar(64)"},
+		{"Tom", 100000, "longtext"},
+		{true, 0, "tinyint(1)"},
+		{123, 0, "bigint"},
+		{int32(123), 0, "int"},
+		{uint64(123), 0, "bigint unsigned"},
+		{1.2, 0, "double"},
+		{[]byte("abc"), 0, "longblob"},
+		{time.Now(), 0, "datetime(6)"},
+	}
+
+	for _, c := range cases {
+		if typ := dial.DataTypeOf(reflect.ValueOf(c.Value), c.Size); typ != c.Type {
+			t.Fatalf("expect %s, but got %s", c.Type, typ)
+		}
+	}
+}
+
+func TestMysql_SQL(t *testing.T) {
+	dial, ok := GetDialect("mysql")
+	if !ok {
+		t.Fatal("mysql dialect is not registered")
+	}
+	sql, args := dial.TableExistSQL("User")
+	if sql != "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?" ||
+		!reflect.DeepEqual(args, []interface{}{"User"}) {
+		t.Fatal("failed to generate table exist sql, got", sql, args)
+	}
+	if q := dial.Quote("we`ird"); q != "`we``ird`" {
+		t.Fatal("failed to quote identifier, got", q)
+	}
+	if sql := dial.RollbackToSavepointSQL("sp_1"); sql != "ROLLBACK TO SAVEPOINT `sp_1`" {
+		t.Fatal("failed to generate savepoint sql, got", sql)
+	}
+	if dial.AutoIncrement() != "AUTO_INCREMENT" {
+		t.Fatal("failed to get auto increment keyword")
+	}
+}
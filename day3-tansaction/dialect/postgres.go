@@ -0,0 +1,134 @@
+package dialect
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"geeorm/clause"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 增加对PostgreSQL的支持
+type postgres struct {
+}
+
+var _ Dialect = (*postgres)(nil)
+
+func init() {
+	RegisterDialect("postgres", &postgres{})
+}
+
+// 将Go语言的类型映射为PostgreSQL中的数据类型
+func (p *postgres) DataTypeOf(typ reflect.Value, size int) string {
+	typ = indirectValue(typ)
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint"
+	case reflect.Int32, reflect.Uint16:
+		return "integer"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "bigint"
+	case reflect.Float32:
+		return "real"
+	case reflect.Float64:
+		return "double precision"
+	case reflect.String:
+		if size > 0 {
+			return fmt.Sprintf("varchar(%d)", size)
+		}
+		return "text"
+	case reflect.Array, reflect.Slice:
+		if typ.Type() == reflect.TypeOf(json.RawMessage{}) {
+			return "jsonb"
+		}
+		return "bytea"
+	case reflect.Struct:
+		if _, ok := typ.Interface().(time.Time); ok {
+			return "timestamptz"
+		}
+	}
+	panic(fmt.Sprintf("invalid sql type %s (%s)", typ.Type().Name(), typ.Kind()))
+}
+
+// 通过pg_catalog判断当前schema中表tableName是否存在
+func (p *postgres) TableExistSQL(tableName string) (string, []interface{}) {
+	args := []interface{}{tableName}
+	return "SELECT tablename FROM pg_catalog.pg_tables WHERE schemaname = current_schema() AND tablename = $1", args
+}
+
+// PostgreSQL使用双引号包裹标识符
+func (p *postgres) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (p *postgres) AutoIncrement() string {
+	return "GENERATED BY DEFAULT AS IDENTITY"
+}
+
+func (p *postgres) AutoIncrementType(typ string) string {
+	return typ
+}
+
+// PostgreSQL的驱动不支持LastInsertId，通过RETURNING返回插入的主键
+func (p *postgres) InsertReturning(column string) string {
+	return "RETURNING " + column
+}
+
+func (p *postgres) FirstInsertID(lastInsertID int64, rows int) int64 {
+	return lastInsertID
+}
+
+// PostgreSQL使用二进制存储的jsonb，支持索引和比较
+func (p *postgres) JSONType() string {
+	return "jsonb"
+}
+
+// PostgreSQL的bytea使用'\x..'的十六进制写法
+func (p *postgres) Literal(v interface{}) string {
+	if x, ok := clause.LiteralValue(v).([]byte); ok {
+		return fmt.Sprintf(`'\x%x'`, x)
+	}
+	return clause.Literal(v)
+}
+
+// PostgreSQL的占位符为$1, $2, ...
+func (p *postgres) Placeholder(index int) string {
+	return fmt.Sprintf("$%d", index)
+}
+
+// PostgreSQL的保存点语句
+func (p *postgres) SavepointSQL(name string) string {
+	return "SAVEPOINT " + p.Quote(name)
+}
+
+func (p *postgres) RollbackToSavepointSQL(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + p.Quote(name)
+}
+
+func (p *postgres) ReleaseSavepointSQL(name string) string {
+	return "RELEASE SAVEPOINT " + p.Quote(name)
+}
+
+// PostgreSQL支持四种标准隔离级别（READ UNCOMMITTED按READ COMMITTED处理）和只读事务
+func (p *postgres) CheckTxOptions(opts *sql.TxOptions) error {
+	switch opts.Isolation {
+	case sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable:
+		return nil
+	}
+	return fmt.Errorf("postgres does not support isolation level %s", opts.Isolation)
+}
+
+// 40001为serialization_failure，40P01为deadlock_detected
+func (p *postgres) IsRetryable(err error) bool {
+	var e sqlStateError
+	if errors.As(err, &e) {
+		code := e.SQLState()
+		return code == "40001" || code == "40P01"
+	}
+	return false
+}
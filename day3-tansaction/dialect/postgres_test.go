@@ -0,0 +1,46 @@
+package dialect
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPostgres_DataTypeOf(t *testing.T) {
+	dial := &postgres{}
+	cases := []struct {
+		Value interface{}
+		Size  int
+		Type  string
+	}{
+		{"Tom", 0, "text"},
+		{"Tom", 64, "varchar(64)"},
+		{true, 0, "boolean"},
+		{123, 0, "bigint"},
+		{int32(123), 0, "integer"},
+		{1.2, 0, "double precision"},
+		{[]byte("abc"), 0, "bytea"},
+		{json.RawMessage(`{}`), 0, "jsonb"},
+		{time.Now(), 0, "timestamptz"},
+	}
+
+	for _, c := range cases {
+		if typ := dial.DataTypeOf(reflect.ValueOf(c.Value), c.Size); typ != c.Type {
+			t.Fatalf("expect %s, but got %s", c.Type, typ)
+		}
+	}
+}
+
+func TestPostgres_Placeholder(t *testing.T) {
+	dial, ok := GetDialect("postgres")
+	if !ok {
+		t.Fatal("postgres dialect is not registered")
+	}
+	if p := dial.Placeholder(2); p != "$2" {
+		t.Fatal("expect $2, but got", p)
+	}
+	if q := dial.Quote("User"); q != `"User"` {
+		t.Fatal("failed to quote identifier, got", q)
+	}
+}
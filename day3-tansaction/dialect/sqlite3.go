@@ -1,8 +1,12 @@
 package dialect
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"geeorm/clause"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -10,15 +14,16 @@ import (
 type sqlite3 struct {
 }
 
-var _Dialect = (*sqlite3)(nil)
+var _ Dialect = (*sqlite3)(nil)
 
 // 将sqlite中的dialect自动注册到全部，&取地址符
 func init() {
 	RegisterDialect("sqlite3", &sqlite3{})
 }
 
-// 将Go语言的类型映射为SQLite中的数据类型
-func (s *sqlite3) DataTypeOf(typ reflect.Value) string {
+// 将Go语言的类型映射为SQLite中的数据类型，SQLite不限制长度，size被忽略
+func (s *sqlite3) DataTypeOf(typ reflect.Value, size int) string {
+	typ = indirectValue(typ)
 	switch typ.Kind() {
 	case reflect.Bool:
 		return "bool"
@@ -45,3 +50,79 @@ func (s *sqlite3) TableExistSQL(tableName string) (string, []interface{}) {
 	args := []interface{}{tableName}
 	return "SELECT name FROM sqlite_master WHERE type='table' and name=?", args
 }
+
+// SQLite使用双引号包裹标识符
+func (s *sqlite3) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// SQLite只允许INTEGER PRIMARY KEY列使用AUTOINCREMENT
+func (s *sqlite3) AutoIncrement() string {
+	return "AUTOINCREMENT"
+}
+
+// SQLite只有声明为INTEGER的主键才是rowid的别名，才能使用AUTOINCREMENT
+func (s *sqlite3) AutoIncrementType(typ string) string {
+	return "integer"
+}
+
+// SQLite 3.35才支持RETURNING，这里统一使用LastInsertId
+func (s *sqlite3) InsertReturning(column string) string {
+	return ""
+}
+
+// SQLite的LastInsertId是最后插入的一行的rowid，同一条语句插入的行的rowid是连续的
+func (s *sqlite3) FirstInsertID(lastInsertID int64, rows int) int64 {
+	return lastInsertID - int64(rows) + 1
+}
+
+// SQLite没有JSON类型，JSON以文本存储，可以使用json_extract等函数查询。
+// 不能使用json作为类型名，否则列会获得NUMERIC亲和性
+func (s *sqlite3) JSONType() string {
+	return "text"
+}
+
+// SQLite的字符串只转义单引号，二进制数据写作X'..'
+func (s *sqlite3) Literal(v interface{}) string {
+	return clause.Literal(v)
+}
+
+func (s *sqlite3) Placeholder(index int) string {
+	return "?"
+}
+
+// SQLite的保存点语句
+func (s *sqlite3) SavepointSQL(name string) string {
+	return "SAVEPOINT " + s.Quote(name)
+}
+
+func (s *sqlite3) RollbackToSavepointSQL(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + s.Quote(name)
+}
+
+func (s *sqlite3) ReleaseSavepointSQL(name string) string {
+	return "RELEASE SAVEPOINT " + s.Quote(name)
+}
+
+// SQLite的事务只有DEFERRED、IMMEDIATE、EXCLUSIVE三种加锁方式，本身就是可串行化的，
+// 因此只接受默认和SERIALIZABLE隔离级别，也不支持只读事务
+func (s *sqlite3) CheckTxOptions(opts *sql.TxOptions) error {
+	if opts.Isolation != sql.LevelDefault && opts.Isolation != sql.LevelSerializable {
+		return fmt.Errorf("sqlite3 does not support isolation level %s", opts.Isolation)
+	}
+	if opts.ReadOnly {
+		return errors.New("sqlite3 does not support read-only transactions")
+	}
+	return nil
+}
+
+// SQLITE_BUSY和SQLITE_LOCKED可以重试，驱动没有导出错误码接口，这里根据错误信息判断
+func (s *sqlite3) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
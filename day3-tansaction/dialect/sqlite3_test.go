@@ -1,6 +1,8 @@
 package dialect
 
 import (
+	"database/sql"
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -18,8 +20,38 @@ func TestDataTypeOf(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		if typ := dial.DataTypeOf(reflect.ValueOf(c.Value)); typ != c.Type {
+		if typ := dial.DataTypeOf(reflect.ValueOf(c.Value), 0); typ != c.Type {
 			t.Fatalf("expect %s, but got %s", c.Type, typ)
 		}
 	}
 }
+
+func TestCheckTxOptions(t *testing.T) {
+	sqliteDial, mysqlDial := &sqlite3{}, &mysql{}
+	if sqliteDial.CheckTxOptions(&sql.TxOptions{Isolation: sql.LevelSerializable}) != nil ||
+		sqliteDial.CheckTxOptions(&sql.TxOptions{Isolation: sql.LevelReadCommitted}) == nil ||
+		sqliteDial.CheckTxOptions(&sql.TxOptions{ReadOnly: true}) == nil {
+		t.Fatal("failed to check sqlite3 tx options")
+	}
+	if mysqlDial.CheckTxOptions(&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}) != nil ||
+		mysqlDial.CheckTxOptions(&sql.TxOptions{Isolation: sql.LevelSnapshot}) == nil {
+		t.Fatal("failed to check mysql tx options")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !(&sqlite3{}).IsRetryable(errors.New("database is locked")) || (&sqlite3{}).IsRetryable(errors.New("no such table")) {
+		t.Fatal("failed to classify sqlite3 errors")
+	}
+	if !(&mysql{}).IsRetryable(errors.New("Error 1213: Deadlock found")) {
+		t.Fatal("failed to classify mysql errors")
+	}
+	if !(&postgres{}).IsRetryable(sqlStateErr("40001")) || (&postgres{}).IsRetryable(sqlStateErr("23505")) {
+		t.Fatal("failed to classify postgres errors")
+	}
+}
+
+type sqlStateErr string
+
+func (e sqlStateErr) Error() string    { return "pq: " + string(e) }
+func (e sqlStateErr) SQLState() string { return string(e) }
@@ -0,0 +1,78 @@
+package dialect
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"time"
+)
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// 将可空类型和自定义类型转换为决定列类型的基础类型的零值，供各dialect的DataTypeOf使用：
+//   - 指针（可空列）使用其指向的类型
+//   - sql.NullString等只有一个值字段和Valid字段的结构体使用值字段的类型
+//   - 实现了driver.Valuer的类型使用其零值Value()返回值的类型
+//   - 无法确定类型的Scanner和Valuer按字符串处理，可以用type标签指定列类型
+func indirectValue(typ reflect.Value) reflect.Value {
+	t := typ.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return reflect.Zero(t)
+	}
+	if t.Kind() == reflect.Struct {
+		if value, ok := nullValueField(t); ok {
+			return indirectValue(reflect.Zero(value))
+		}
+	}
+	isValuer := t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType)
+	if isValuer {
+		if v := zeroDriverValue(t); v != nil {
+			return reflect.ValueOf(v)
+		}
+	}
+	if (isValuer || reflect.PtrTo(t).Implements(scannerType)) && t.Kind() == reflect.Struct {
+		return reflect.ValueOf("")
+	}
+	return reflect.Zero(t)
+}
+
+// 判断t是否是sql.NullString、sql.Null[T]这样的结构体，返回其值字段的类型
+func nullValueField(t reflect.Type) (reflect.Type, bool) {
+	if t.NumField() != 2 {
+		return nil, false
+	}
+	valid, ok := t.FieldByName("Valid")
+	if !ok || valid.Type.Kind() != reflect.Bool {
+		return nil, false
+	}
+	value := t.Field(0)
+	if value.Index[0] == valid.Index[0] {
+		value = t.Field(1)
+	}
+	return value.Type, true
+}
+
+// 调用t的零值的Value方法，出错或者panic时返回nil
+func zeroDriverValue(t reflect.Type) (v driver.Value) {
+	defer func() {
+		if recover() != nil {
+			v = nil
+		}
+	}()
+	valuer, ok := reflect.New(t).Interface().(driver.Valuer)
+	if !ok {
+		return nil
+	}
+	v, err := valuer.Value()
+	if err != nil {
+		return nil
+	}
+	return v
+}
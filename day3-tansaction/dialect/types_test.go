@@ -0,0 +1,101 @@
+package dialect
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 以分为单位存储的金额
+type money struct {
+	cents int64
+}
+
+func (m money) Value() (driver.Value, error) {
+	return m.cents, nil
+}
+
+func (m *money) Scan(src interface{}) error {
+	cents, ok := src.(int64)
+	if !ok {
+		return errors.New("invalid money")
+	}
+	m.cents = cents
+	return nil
+}
+
+type uuid [16]byte
+
+// 只实现了Scanner，无法推断列类型
+type point struct {
+	X, Y float64
+}
+
+func (p *point) Scan(src interface{}) error {
+	return nil
+}
+
+func TestDataTypeOf_Nullable(t *testing.T) {
+	sqliteDial, mysqlDial := &sqlite3{}, &mysql{}
+	s := "Tom"
+	cases := []struct {
+		Value  interface{}
+		SQLite string
+		MySQL  string
+	}{
+		{&s, "text", "varchar(255)"},
+		{(*int64)(nil), "bigint", "bigint"},
+		{(**bool)(nil), "bool", "tinyint(1)"},
+		{sql.NullString{}, "text", "varchar(255)"},
+		{sql.NullInt32{}, "integer", "int"},
+		{sql.NullTime{}, "datetime", "datetime(6)"},
+		{&sql.NullFloat64{}, "real", "double"},
+		{money{}, "bigint", "bigint"},
+		{&money{}, "bigint", "bigint"},
+		{uuid{}, "blob", "longblob"},
+		{point{}, "text", "varchar(255)"},
+		{time.Time{}, "datetime", "datetime(6)"},
+	}
+	for _, c := range cases {
+		v := reflect.ValueOf(c.Value)
+		if typ := sqliteDial.DataTypeOf(v, 0); typ != c.SQLite {
+			t.Fatalf("expect %s for %T, but got %s", c.SQLite, c.Value, typ)
+		}
+		if typ := mysqlDial.DataTypeOf(v, 0); typ != c.MySQL {
+			t.Fatalf("expect %s for %T, but got %s", c.MySQL, c.Value, typ)
+		}
+	}
+}
+
+func TestJSONType(t *testing.T) {
+	if (&sqlite3{}).JSONType() != "text" || (&mysql{}).JSONType() != "json" || (&postgres{}).JSONType() != "jsonb" {
+		t.Fatal("unexpected json column type")
+	}
+}
+
+func TestInsertID(t *testing.T) {
+	if (&sqlite3{}).FirstInsertID(5, 3) != 3 || (&mysql{}).FirstInsertID(3, 3) != 3 {
+		t.Fatal("unexpected first insert id")
+	}
+	if (&sqlite3{}).InsertReturning("ID") != "" || (&postgres{}).InsertReturning("ID") != "RETURNING ID" {
+		t.Fatal("unexpected returning clause")
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	if got := (&sqlite3{}).Literal([]byte("ab")); got != "X'6162'" {
+		t.Fatal("unexpected sqlite3 literal", got)
+	}
+	if got := (&postgres{}).Literal([]byte("ab")); got != `'\x6162'` {
+		t.Fatal("unexpected postgres literal", got)
+	}
+	if got := (&mysql{}).Literal(`a\'b`); got != `'a\\''b'` {
+		t.Fatal("unexpected mysql literal", got)
+	}
+	if got := (&postgres{}).Literal(`a\'b`); got != `'a\''b'` {
+		t.Fatal("unexpected postgres literal", got)
+	}
+}
@@ -1,67 +1,175 @@
 package geeorm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"geeorm/dialect"
 	"geeorm/log"
+	"geeorm/schema"
 	"geeorm/session"
 	"strings"
+	"time"
 )
 
 type Engine struct {
 	db      *sql.DB
 	dialect dialect.Dialect
+	naming  schema.NamingStrategy
+	//解析好的Schema，由所有Session共享
+	schemas *schema.Cache
+	//对所有模型生效的回调，例如审计、多租户过滤、监控等插件
+	callbacks *session.Callbacks
+	//预编译语句缓存，未开启时为nil
+	stmts *session.StmtCache
+	//日志输出、慢查询阈值和日志参数的截断长度，传递给每一个Session
+	logger     log.Logger
+	slow       time.Duration
+	valueLimit int
+	//db由NewEngine打开时为true，Close时一并关闭；NewEngineFromDB传入的db由调用方负责关闭
+	ownsDB bool
 }
 
 // 新增事务，为用户提供一键式使用的窗口
-type TxFunc func(*session.Session) (interface{}, error)
+type TxFunc = session.TxFunc
 
 func (engine *Engine) Transaction(f TxFunc) (result interface{}, err error) {
-	s := engine.NewSession()
-	if err := s.Begin(); err != nil {
-		return nil, err
-	}
-	defer func() {
-		//recover函数：捕获并处理程序中发生的panic异常，只能在defer函数中调用，
-		//通常和panic配合使用，没有发生panic则返回nil，
-		//注意！需要在发生panic的代码块之前定义，否则无法捕获。
-		//另外，recover（）只能捕获当前goroutine中的panic，无法捕捉到其他goroutine的异常
-		if p := recover(); p != nil {
-			_ = s.Rollback()
-			panic(p)
-		} else if err != nil {
-			_ = s.Rollback()
-		} else {
-			err = s.Commit()
-		}
-	}()
-	return f(s)
+	return engine.TransactionContext(context.Background(), f)
+}
+
+// 使用指定的隔离级别和只读标记执行事务，数据库不支持的选项会返回错误
+func (engine *Engine) TransactionWith(opts *sql.TxOptions, f TxFunc) (result interface{}, err error) {
+	return engine.NewSession().TransactionWith(opts, f)
 }
 
-func NewEngine(driver, source string) (e *Engine, err error) {
+// 在给定上下文中执行事务，ctx被取消或超时时事务会被回滚。
+// 在f中需要嵌套事务时应调用s.Transaction，它会使用保存点而不是开启一个新的事务
+func (engine *Engine) TransactionContext(ctx context.Context, f TxFunc) (result interface{}, err error) {
+	return engine.NewSession().WithContext(ctx).Transaction(f)
+}
 
+func NewEngine(driver, source string, opts ...Option) (e *Engine, err error) {
 	db, err := sql.Open(driver, source)
 	if err != nil {
-		log.Error(err)
+		newOptions(driver, opts).logger.Error(context.Background(), "open database failed", log.Any(log.FieldError, err))
+		return
+	}
+	if e, err = NewEngineFromDB(db, driver, opts...); err != nil {
+		_ = db.Close()
+		return
+	}
+	e.ownsDB = true
+	return
+}
+
+// 使用调用方已经打开的*sql.DB创建Engine，dialectName用于查找已注册的dialect。
+// db仍然归调用方所有，Engine.Close不会关闭它，调用方需要在不再使用时自行关闭
+func NewEngineFromDB(db *sql.DB, dialectName string, opts ...Option) (e *Engine, err error) {
+	o := newOptions(dialectName, opts)
+	ctx := context.Background()
+	//先确认dialect已经注册，避免Session在使用时因dialect为nil而panic
+	dial, ok := dialect.GetDialect(o.dialect)
+	if !ok {
+		err = fmt.Errorf("dialect %s Not Found", o.dialect)
+		o.logger.Error(ctx, "create engine failed", log.Any(log.FieldError, err))
 		return
 	}
-	if err = db.Ping(); err != nil {
-		log.Error(err)
+	for _, set := range o.pool {
+		set(db)
+	}
+
+	if o.pingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.pingTimeout)
+		defer cancel()
+	}
+	if err = db.PingContext(ctx); err != nil {
+		o.logger.Error(ctx, "ping database failed", log.Any(log.FieldError, err))
 		return
 	}
-	e = &Engine{db: db}
-	log.Info("Connect database success")
+	e = &Engine{
+		db:         db,
+		dialect:    dial,
+		naming:     o.naming,
+		schemas:    schema.NewCache(o.logger),
+		callbacks:  session.NewCallbacks(),
+		logger:     o.logger,
+		slow:       o.slow,
+		valueLimit: o.valueLimit,
+	}
+	if o.stmtCache > 0 {
+		e.stmts = session.NewStmtCache(db, o.stmtCache, o.logger)
+	}
+	e.logger.Info(ctx, "Connect database success")
 	return
 }
+
+// 返回Engine使用的dialect
+func (engine *Engine) Dialect() dialect.Dialect {
+	return engine.dialect
+}
+
+// 返回Engine的回调注册表，插件通过它注册、替换或删除回调
+func (engine *Engine) Callback() *session.Callbacks {
+	return engine.callbacks
+}
+
+// 返回预编译语句缓存的统计信息，未开启缓存时返回零值
+func (engine *Engine) StmtCacheStats() session.StmtCacheStats {
+	if engine.stmts == nil {
+		return session.StmtCacheStats{}
+	}
+	return engine.stmts.Stats()
+}
+
+// 返回Engine使用的Logger
+func (engine *Engine) Logger() log.Logger {
+	return engine.logger
+}
+
+// 返回Engine底层的*sql.DB
+func (engine *Engine) DB() *sql.DB {
+	return engine.db
+}
+
+// 返回连接池的统计信息
+func (engine *Engine) Stats() sql.DBStats {
+	return engine.db.Stats()
+}
+
+// 关闭预编译语句缓存，以及由NewEngine打开的数据库连接
 func (engine *Engine) Close() {
+	if engine.stmts != nil {
+		engine.stmts.Close()
+	}
+	if !engine.ownsDB {
+		return
+	}
 	if err := engine.db.Close(); err != nil {
-		log.Error("Failed to close database")
+		engine.logger.Error(context.Background(), "Failed to close database", log.Any(log.FieldError, err))
+		return
 	}
-	log.Info("Close database success")
+	engine.logger.Info(context.Background(), "Close database success")
 }
 func (engine *Engine) NewSession() *session.Session {
-	return session.New(engine.db, engine.dialect)
+	return session.New(engine.db, engine.dialect,
+		session.WithNamingStrategy(engine.naming),
+		session.WithSchemaCache(engine.schemas),
+		session.WithCallbacks(engine.callbacks),
+		session.WithStmtCache(engine.stmts),
+		session.WithLogger(engine.logger),
+		session.WithSlowThreshold(engine.slow),
+		session.WithLogValueLimit(engine.valueLimit))
+}
+
+// Model 返回一个设置了模型的新会话，每次调用都从空的查询状态开始
+func (engine *Engine) Model(value interface{}) *session.Session {
+	return engine.NewSession().Model(value)
+}
+
+// Where 返回一个设置了查询条件的新会话，每次调用都从空的查询状态开始
+func (engine *Engine) Where(desc string, args ...interface{}) *session.Session {
+	return engine.NewSession().Where(desc, args...)
 }
 
 // difference returns a - b
@@ -80,21 +188,32 @@ func difference(a []string, b []string) (diff []string) {
 
 // Migrate table
 func (engine *Engine) Migrate(value interface{}) error {
-	_, err := engine.Transaction(func(s *session.Session) (result interface{}, err error) {
-		if !s.Model(value).HasTable() {
-			log.Infof("table %s doesn't exist", s.RefTable().Name)
+	return engine.MigrateContext(context.Background(), value)
+}
+
+// MigrateContext migrates table within ctx
+func (engine *Engine) MigrateContext(ctx context.Context, value interface{}) error {
+	_, err := engine.TransactionContext(ctx, func(s *session.Session) (result interface{}, err error) {
+		s = s.Model(value)
+		if !s.HasTable() {
+			s.Logger().Info(ctx, fmt.Sprintf("table %s doesn't exist", s.RefTable().Name))
 			return nil, s.CreateTable()
 		}
 		table := s.RefTable()
-		rows, _ := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", table.Name)).QueryRows()
+		quote := engine.dialect.Quote
+		rows, err := s.Raw(fmt.Sprintf("SELECT * FROM %s LIMIT 1", quote(table.Name))).QueryRows()
+		if err != nil {
+			return
+		}
 		columns, _ := rows.Columns()
+		_ = rows.Close()
 		addCols := difference(table.FieldNames, columns)
 		delCols := difference(columns, table.FieldNames)
-		log.Infof("added cols %v, deleted cols %v", addCols, delCols)
+		s.Logger().Info(ctx, "migrate columns", log.Any("added", addCols), log.Any("deleted", delCols))
 
 		for _, col := range addCols {
 			f := table.GetField(col)
-			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table.Name, f.Name, f.Type)
+			sqlStr := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", quote(table.Name), quote(f.ColumnName), f.Type)
 			if _, err = s.Raw(sqlStr).Exec(); err != nil {
 				return
 			}
@@ -103,12 +222,16 @@ func (engine *Engine) Migrate(value interface{}) error {
 		if len(delCols) == 0 {
 			return
 		}
-		tmp := "tmp_" + table.Name
-		fieldStr := strings.Join(table.FieldNames, ", ")
-		s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s from %s;", tmp, fieldStr, table.Name))
-		s.Raw(fmt.Sprintf("DROP TABLE %s;", table.Name))
-		s.Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, table.Name))
-		_, err = s.Exec()
+		tmp, name := quote("tmp_"+table.Name), quote(table.Name)
+		var quoted []string
+		for _, column := range table.FieldNames {
+			quoted = append(quoted, quote(column))
+		}
+		fieldStr := strings.Join(quoted, ", ")
+		_, err = s.Raw(fmt.Sprintf("CREATE TABLE %s AS SELECT %s from %s;", tmp, fieldStr, name)).
+			Raw(fmt.Sprintf("DROP TABLE %s;", name)).
+			Raw(fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tmp, name)).
+			Exec()
 		return
 	})
 	return err
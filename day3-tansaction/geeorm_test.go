@@ -1,11 +1,17 @@
 package geeorm
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"geeorm/log"
+	"geeorm/schema"
 	"geeorm/session"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func OpenDB(t *testing.T) *Engine {
@@ -17,11 +23,157 @@ func OpenDB(t *testing.T) *Engine {
 	return engine
 }
 
+func init() {
+	sql.Register("sqlite3_wrapped", &sqlite3.SQLiteDriver{})
+}
+
+func TestNewEngine_Dialect(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	if engine.Dialect() == nil || s.CreateTable() != nil || !s.HasTable() {
+		t.Fatal("failed to resolve dialect")
+	}
+	if _, err := NewEngine("sqlite3_wrapped", "gee.db"); err == nil {
+		t.Fatal("expect error for driver without dialect")
+	}
+	wrapped, err := NewEngine("sqlite3_wrapped", "gee.db", WithDialect("sqlite3"))
+	if err != nil || wrapped.Dialect() != engine.Dialect() {
+		t.Fatal("failed to override dialect", err)
+	}
+	wrapped.Close()
+}
+
+func TestNewEngineFromDB(t *testing.T) {
+	db, _ := sql.Open("sqlite3", "gee.db")
+	defer db.Close()
+	if _, err := NewEngineFromDB(db, "unknown"); err == nil {
+		t.Fatal("expect error for unknown dialect")
+	}
+	engine, err := NewEngineFromDB(db, "sqlite3",
+		WithMaxOpenConns(3),
+		WithMaxIdleConns(1),
+		WithConnMaxLifetime(time.Minute),
+		WithPingTimeout(time.Second),
+		WithNamingStrategy(schema.Naming{TablePrefix: "t_"}))
+	if err != nil || engine.DB() != db {
+		t.Fatal("failed to create engine from db", err)
+	}
+	if engine.Stats().MaxOpenConnections != 3 {
+		t.Fatal("failed to set max open conns, got", engine.Stats().MaxOpenConnections)
+	}
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	if s.RefTable().Name != "t_User" || s.CreateTable() != nil || !s.HasTable() {
+		t.Fatal("failed to apply naming strategy")
+	}
+	_ = s.DropTable()
+	//db由调用方管理，Engine.Close不会关闭它
+	engine.Close()
+	if err = db.Ping(); err != nil {
+		t.Fatal("expect db passed to NewEngineFromDB to stay open", err)
+	}
+}
+
+func TestEngine_Callback(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	var tables []string
+	_ = engine.Callback().Before(session.OpQuery, "collect", func(scope *session.Scope) error {
+		tables = append(tables, scope.Table.Name)
+		return nil
+	})
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Count()
+	if len(tables) != 1 || tables[0] != "User" {
+		t.Fatal("failed to call engine callbacks, got", tables)
+	}
+}
+
+func TestEngine_Model(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(&User{"Tom", 18}, &User{"Sam", 25})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := &User{}
+			if err := engine.Where("Name = ?", "Sam").First(u); err != nil || u.Age != 25 {
+				t.Error("failed to query from engine", err)
+			}
+			if count, err := engine.Model(&User{}).Count(); err != nil || count != 2 {
+				t.Error("expect engine.Model to start a fresh query, got", count, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEngine_StmtCache(t *testing.T) {
+	engine, err := NewEngine("sqlite3", "gee.db", WithStmtCache(8))
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	defer engine.Close()
+	s := engine.Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	for i := 0; i < 3; i++ {
+		_, _ = s.Count()
+	}
+	if stats := engine.StmtCacheStats(); stats.Hits < 2 || stats.Misses == 0 {
+		t.Fatal("expect engine to reuse prepared statements", stats)
+	}
+}
+
 type User struct {
 	Name string `geeorm:"PRIMARY KEY"`
 	Age  int
 }
 
+// 只记录消息的Logger
+type msgLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *msgLogger) add(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, msg)
+}
+
+func (l *msgLogger) Info(ctx context.Context, msg string, fields ...log.Field)  { l.add(msg) }
+func (l *msgLogger) Warn(ctx context.Context, msg string, fields ...log.Field)  { l.add(msg) }
+func (l *msgLogger) Error(ctx context.Context, msg string, fields ...log.Field) { l.add(msg) }
+
+func TestEngine_Logger(t *testing.T) {
+	logger := &msgLogger{}
+	engine, err := NewEngine("sqlite3", "gee.db", WithLogger(logger), WithSlowThreshold(time.Nanosecond))
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	defer engine.Close()
+	if engine.Logger() != logger {
+		t.Fatal("failed to set logger")
+	}
+	if _, err = engine.NewSession().Raw("SELECT 1").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(logger.msgs, []string{"Connect database success", "slow sql"}) {
+		t.Fatal("unexpected log messages", logger.msgs)
+	}
+}
+
 func TestEngine_Transaction(t *testing.T) {
 	t.Run("rollback", func(t *testing.T) {
 		transactionRollback(t)
@@ -29,6 +181,96 @@ func TestEngine_Transaction(t *testing.T) {
 	t.Run("commit", func(t *testing.T) {
 		transactionCommit(t)
 	})
+	t.Run("canceled", func(t *testing.T) {
+		transactionCanceled(t)
+	})
+	t.Run("hook", func(t *testing.T) {
+		transactionHookError(t)
+	})
+	t.Run("options", func(t *testing.T) {
+		transactionOptions(t)
+	})
+	t.Run("after commit", func(t *testing.T) {
+		transactionAfterCommit(t)
+	})
+}
+
+func transactionAfterCommit(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	committed, rolledBack := false, false
+	_, err := engine.Transaction(func(s *session.Session) (interface{}, error) {
+		s.AfterCommit(func() { committed = true })
+		s.AfterRollback(func() { rolledBack = true })
+		if committed {
+			t.Fatal("AfterCommit must not run before commit")
+		}
+		return nil, nil
+	})
+	if err != nil || !committed || rolledBack {
+		t.Fatal("failed to run after commit callbacks")
+	}
+}
+
+func transactionOptions(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	_, err := engine.TransactionWith(&sql.TxOptions{Isolation: sql.LevelSerializable}, func(s *session.Session) (interface{}, error) {
+		return s.Model(&User{}).Count()
+	})
+	if err != nil {
+		t.Fatal("failed to run serializable transaction", err)
+	}
+	if _, err = engine.TransactionWith(&sql.TxOptions{ReadOnly: true}, func(s *session.Session) (interface{}, error) {
+		return nil, nil
+	}); err == nil {
+		t.Fatal("expect read-only transaction to be rejected by sqlite3")
+	}
+}
+
+type Player struct {
+	Name string `geeorm:"PRIMARY KEY"`
+	Age  int
+}
+
+func (p *Player) BeforeInsert(s *session.Session) error {
+	if p.Age < 0 {
+		return errors.New("age must not be negative")
+	}
+	return nil
+}
+
+func transactionHookError(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession().Model(&Player{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, err := engine.Transaction(func(s *session.Session) (result interface{}, err error) {
+		if _, err = s.Insert(&Player{"Tom", 18}); err != nil {
+			return
+		}
+		_, err = s.Insert(&Player{"Sam", -1})
+		return
+	})
+	if count, _ := s.Count(); err == nil || count != 0 {
+		t.Fatal("failed to rollback on hook error")
+	}
+}
+
+func transactionCanceled(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	_, err := engine.TransactionContext(ctx, func(s *session.Session) (result interface{}, err error) {
+		called = true
+		return
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Fatal("expect canceled transaction, but got", err)
+	}
 }
 
 func transactionRollback(t *testing.T) {
@@ -78,3 +320,78 @@ func TestEngine_Migrate(t *testing.T) {
 		t.Fatal("Failed to migrate table User, got columns", columns)
 	}
 }
+
+// 删除列时执行的多条语句不能使用预编译语句缓存，否则只有第一条会被执行
+func TestEngine_MigrateStmtCache(t *testing.T) {
+	engine, err := NewEngine("sqlite3", "gee.db", WithStmtCache(8))
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("DROP TABLE IF EXISTS tmp_User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text PRIMARY KEY, Age integer, Old text);").Exec()
+	if err = engine.Migrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := s.Raw("SELECT * FROM User").QueryRows()
+	columns, _ := rows.Columns()
+	_ = rows.Close()
+	if !reflect.DeepEqual(columns, []string{"Name", "Age"}) {
+		t.Fatal("Failed to migrate table User with statement cache, got columns", columns)
+	}
+	var n int
+	if err = s.Raw("SELECT count(*) FROM sqlite_master WHERE name = ?", "tmp_User").QueryRow().Scan(&n); err != nil || n != 0 {
+		t.Fatal("expect temporary table to be renamed", n, err)
+	}
+}
+
+type Staff struct {
+	Name  string `geeorm:"column:staff_name;primary key"`
+	Level int    `geeorm:"column:staff_level"`
+	Cache string `geeorm:"-"`
+}
+
+func TestEngine_MigrateColumnTags(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession()
+	_, _ = s.Raw("DROP TABLE IF EXISTS Staff;").Exec()
+	_, _ = s.Raw("CREATE TABLE Staff(staff_name text PRIMARY KEY, Name text);").Exec()
+	if err := engine.Migrate(&Staff{}); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := s.Raw("SELECT * FROM Staff").QueryRows()
+	columns, _ := rows.Columns()
+	_ = rows.Close()
+	if !reflect.DeepEqual(columns, []string{"staff_name", "staff_level"}) {
+		t.Fatal("Failed to migrate table Staff, got columns", columns)
+	}
+}
+
+type UserProfile struct {
+	UserID   int64 `geeorm:"primary key"`
+	NickName string
+}
+
+func TestEngine_NamingStrategy(t *testing.T) {
+	engine, err := NewEngine("sqlite3", "gee.db", WithNamingStrategy(schema.Naming{SnakeCase: true, Plural: true}))
+	if err != nil {
+		t.Fatal("failed to connect", err)
+	}
+	defer engine.Close()
+	s := engine.Model(&UserProfile{})
+	_ = s.DropTable()
+	if err = engine.Migrate(&UserProfile{}); err != nil || !s.HasTable() {
+		t.Fatal("failed to create table user_profiles", err)
+	}
+	if _, err = s.Insert(&UserProfile{UserID: 1, NickName: "Tom"}); err != nil {
+		t.Fatal(err)
+	}
+	var p UserProfile
+	if err = engine.Where("nick_name = ?", "Tom").First(&p); err != nil || p.UserID != 1 {
+		t.Fatal("failed to query user_profiles", err)
+	}
+	_ = s.DropTable()
+}
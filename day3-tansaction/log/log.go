@@ -1,23 +1,37 @@
 package log
 
 import (
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"sync"
 )
 
+const (
+	errorPrefix = "\033[31m[error]\033[0m"
+	warnPrefix  = "\033[33m[warn ]\033[0m "
+	infoPrefix  = "\033[34m[info ]\033[0m "
+	flags       = log.LstdFlags | log.Lshortfile
+)
+
 var (
-	errorLog = log.New(os.Stdout, "\033[31m[error]\033[0m", log.LstdFlags|log.Lshortfile)
-	infoLog  = log.New(os.Stdout, "\033[34m[info ]\033[0m ", log.LstdFlags|log.Lshortfile)
-	loggers  = []*log.Logger{errorLog, infoLog}
+	errorLog = log.New(os.Stdout, errorPrefix, flags)
+	warnLog  = log.New(os.Stdout, warnPrefix, flags)
+	infoLog  = log.New(os.Stdout, infoPrefix, flags)
+	loggers  = []*log.Logger{errorLog, warnLog, infoLog}
 	mu       sync.Mutex
+
+	output   io.Writer = os.Stdout
+	curLevel           = InfoLevel
 )
 
 // log methods
 var (
 	Error  = errorLog.Println
 	Errorf = errorLog.Printf
+	Warn   = warnLog.Println
+	Warnf  = warnLog.Printf
 	Info   = infoLog.Println
 	Infof  = infoLog.Printf
 )
@@ -25,6 +39,7 @@ var (
 // log levels
 const (
 	InfoLevel = iota
+	WarnLevel
 	ErrorLevel
 	Disabled
 )
@@ -34,14 +49,31 @@ func SetLevel(level int) {
 	mu.Lock()
 	defer mu.Unlock()
 
+	curLevel = level
+	apply()
+}
+
+// SetOutput redirects all loggers to w, the current level is kept
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	output = w
+	apply()
+}
+
+func apply() {
 	for _, logger := range loggers {
-		logger.SetOutput(os.Stdout)
+		logger.SetOutput(output)
 	}
 
-	if ErrorLevel < level {
+	if ErrorLevel < curLevel {
 		errorLog.SetOutput(ioutil.Discard)
 	}
-	if InfoLevel < level {
+	if WarnLevel < curLevel {
+		warnLog.SetOutput(ioutil.Discard)
+	}
+	if InfoLevel < curLevel {
 		infoLog.SetOutput(ioutil.Discard)
 	}
 }
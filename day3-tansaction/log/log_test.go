@@ -1,7 +1,9 @@
 package log
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -15,3 +17,16 @@ func TestSetLevel(t *testing.T) {
 		t.Fatal("failed to set log level")
 	}
 }
+
+func TestSetOutput(t *testing.T) {
+	var buf bytes.Buffer
+	SetLevel(ErrorLevel)
+	defer SetLevel(InfoLevel)
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	Info("info")
+	Error("error")
+	if !strings.Contains(buf.String(), "error") || strings.Contains(buf.String(), "info") {
+		t.Fatal("failed to set output, got", buf.String())
+	}
+}
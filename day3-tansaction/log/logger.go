@@ -0,0 +1,102 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// Field 是日志中的一个结构化字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Any 创建一个结构化字段
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// SQL日志中使用的字段名
+const (
+	FieldSQL          = "sql"
+	FieldVars         = "vars"
+	FieldDuration     = "duration"
+	FieldRowsAffected = "rows_affected"
+	FieldError        = "error"
+)
+
+// Logger 是geeorm输出日志的接口，Engine和Session通过它记录SQL和事务等信息
+type Logger interface {
+	Info(ctx context.Context, msg string, fields ...Field)
+	Warn(ctx context.Context, msg string, fields ...Field)
+	Error(ctx context.Context, msg string, fields ...Field)
+}
+
+// 基于标准库log的Logger，三个级别各使用一个*log.Logger
+type stdLogger struct {
+	info, warn, error *log.Logger
+}
+
+// Default 返回默认的Logger，使用本包的全局日志输出，受SetLevel和SetOutput控制
+func Default() Logger {
+	return stdLogger{info: infoLog, warn: warnLog, error: errorLog}
+}
+
+// New 返回输出到w的Logger，低于level的日志被丢弃。它不使用全局日志输出，
+// 可以通过WithLogger为每个Engine单独指定，不影响其他Engine
+func New(w io.Writer, level int) Logger {
+	l := stdLogger{
+		info:  log.New(w, infoPrefix, flags),
+		warn:  log.New(w, warnPrefix, flags),
+		error: log.New(w, errorPrefix, flags),
+	}
+	if InfoLevel < level {
+		l.info.SetOutput(ioutil.Discard)
+	}
+	if WarnLevel < level {
+		l.warn.SetOutput(ioutil.Discard)
+	}
+	if ErrorLevel < level {
+		l.error.SetOutput(ioutil.Discard)
+	}
+	return l
+}
+
+// 调用深度为2，使日志中的文件名和行号指向调用Info、Warn、Error的位置
+func (l stdLogger) Info(ctx context.Context, msg string, fields ...Field) {
+	_ = l.info.Output(2, format(msg, fields))
+}
+
+func (l stdLogger) Warn(ctx context.Context, msg string, fields ...Field) {
+	_ = l.warn.Output(2, format(msg, fields))
+}
+
+func (l stdLogger) Error(ctx context.Context, msg string, fields ...Field) {
+	_ = l.error.Output(2, format(msg, fields))
+}
+
+// 将消息和字段格式化为 msg key=value key=value 的形式
+func format(msg string, fields []Field) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteString(fmt.Sprintf(" %s=%v", f.Key, f.Value))
+	}
+	return b.String()
+}
+
+// 丢弃所有日志的Logger
+type discardLogger struct{}
+
+// Discard 返回一个丢弃所有日志的Logger
+func Discard() Logger {
+	return discardLogger{}
+}
+
+func (discardLogger) Info(ctx context.Context, msg string, fields ...Field)  {}
+func (discardLogger) Warn(ctx context.Context, msg string, fields ...Field)  {}
+func (discardLogger) Error(ctx context.Context, msg string, fields ...Field) {}
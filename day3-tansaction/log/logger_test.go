@@ -0,0 +1,37 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// 日志中的文件名和行号应当指向调用Logger的位置
+func TestDefault_Caller(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	_, _, line, _ := runtime.Caller(0)
+	Default().Info(context.Background(), "hello")
+	if want := fmt.Sprintf("logger_test.go:%d:", line+1); !strings.Contains(buf.String(), want) {
+		t.Fatalf("expect %s in log, but got %s", want, buf.String())
+	}
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, WarnLevel)
+	_, _, line, _ := runtime.Caller(0)
+	logger.Warn(context.Background(), "slow", Any(FieldSQL, "SELECT 1"))
+	logger.Info(context.Background(), "ignored")
+	if want := fmt.Sprintf("logger_test.go:%d: slow sql=SELECT 1", line+1); !strings.Contains(buf.String(), want) {
+		t.Fatalf("expect %s in log, but got %s", want, buf.String())
+	}
+	if strings.Contains(buf.String(), "ignored") {
+		t.Fatal("expect info to be discarded, but got", buf.String())
+	}
+}
@@ -0,0 +1,41 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slog适配器，将geeorm的日志转发给*slog.Logger
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger 返回一个使用l输出日志的Logger，l为nil时使用slog.Default()
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l: l}
+}
+
+func (s slogLogger) Info(ctx context.Context, msg string, fields ...Field) {
+	s.l.LogAttrs(ctx, slog.LevelInfo, msg, attrs(fields)...)
+}
+
+func (s slogLogger) Warn(ctx context.Context, msg string, fields ...Field) {
+	s.l.LogAttrs(ctx, slog.LevelWarn, msg, attrs(fields)...)
+}
+
+func (s slogLogger) Error(ctx context.Context, msg string, fields ...Field) {
+	s.l.LogAttrs(ctx, slog.LevelError, msg, attrs(fields)...)
+}
+
+func attrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	return attrs
+}
@@ -0,0 +1,22 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	l.Warn(context.Background(), "slow sql", Any(FieldSQL, "SELECT 1"), Any(FieldRowsAffected, int64(1)))
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, `sql="SELECT 1"`) ||
+		!strings.Contains(out, "rows_affected=1") {
+		t.Fatal("unexpected output", out)
+	}
+}
@@ -0,0 +1,102 @@
+package geeorm
+
+import (
+	"database/sql"
+	"geeorm/log"
+	"geeorm/schema"
+	"time"
+)
+
+// Option 用于在创建Engine时修改默认配置
+type Option func(*options)
+
+type options struct {
+	dialect     string                //显式指定的dialect名称，为空时使用driver名称
+	pool        []func(db *sql.DB)    //连接池相关的设置，在Ping之前依次作用于*sql.DB
+	pingTimeout time.Duration         //Ping的超时时间，0表示不设置超时
+	naming      schema.NamingStrategy //表名和列名的命名规则
+	stmtCache   int                   //预编译语句缓存的容量，0表示不缓存
+	logger      log.Logger            //日志输出
+	slow        time.Duration         //慢查询阈值，0表示不记录慢查询
+	valueLimit  int                   //日志中参数的最大字节数，0表示不截断
+}
+
+func newOptions(dialect string, opts []Option) *options {
+	o := &options{dialect: dialect, logger: log.Default()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithDialect 指定Engine使用的dialect，适用于driver名称与dialect名称不一致的情况，
+// 例如对sqlite3驱动做了包装并以其他名称注册
+func WithDialect(name string) Option {
+	return func(o *options) {
+		o.dialect = name
+	}
+}
+
+// WithMaxOpenConns 设置连接池的最大连接数，见sql.DB.SetMaxOpenConns
+func WithMaxOpenConns(n int) Option {
+	return func(o *options) {
+		o.pool = append(o.pool, func(db *sql.DB) { db.SetMaxOpenConns(n) })
+	}
+}
+
+// WithMaxIdleConns 设置连接池的最大空闲连接数，见sql.DB.SetMaxIdleConns
+func WithMaxIdleConns(n int) Option {
+	return func(o *options) {
+		o.pool = append(o.pool, func(db *sql.DB) { db.SetMaxIdleConns(n) })
+	}
+}
+
+// WithConnMaxLifetime 设置连接可被复用的最长时间，见sql.DB.SetConnMaxLifetime
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		o.pool = append(o.pool, func(db *sql.DB) { db.SetConnMaxLifetime(d) })
+	}
+}
+
+// WithPingTimeout 设置创建Engine时Ping数据库的超时时间
+func WithPingTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.pingTimeout = d
+	}
+}
+
+// WithLogger 设置Engine及其Session使用的Logger，例如log.New(os.Stderr, log.WarnLevel)
+// 或log.NewSlogLogger(slog.Default())
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSlowThreshold 设置慢查询阈值，执行时间不小于d的SQL以warn级别记录
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slow = d
+	}
+}
+
+// WithNamingStrategy 设置结构体名到表名、字段名到列名的命名规则，例如schema.Naming{SnakeCase: true}
+func WithNamingStrategy(naming schema.NamingStrategy) Option {
+	return func(o *options) {
+		o.naming = naming
+	}
+}
+
+// WithStmtCache 开启预编译语句缓存，最多缓存size条SQL对应的*sql.Stmt
+func WithStmtCache(size int) Option {
+	return func(o *options) {
+		o.stmtCache = size
+	}
+}
+
+// WithLogValueLimit 设置日志中字符串和[]byte参数的最大字节数，超过时被截断，0表示不截断
+func WithLogValueLimit(n int) Option {
+	return func(o *options) {
+		o.valueLimit = n
+	}
+}
@@ -0,0 +1,72 @@
+package geeorm
+
+import (
+	"context"
+	"database/sql"
+	"geeorm/log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy 描述事务失败后的重试策略
+type RetryPolicy struct {
+	MaxAttempts int           //最多执行的次数（包括第一次），小于1时按1处理
+	BaseDelay   time.Duration //第一次重试前等待的时间，之后每次翻倍
+	MaxDelay    time.Duration //等待时间的上限，0表示不限制
+	TxOptions   *sql.TxOptions
+	//判断错误是否可以重试，为nil时使用Engine的dialect.IsRetryable
+	Retryable func(err error) bool
+	//每次重试前调用，attempt为即将开始的第几次执行
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultRetryPolicy 返回默认的重试策略：最多执行3次，等待时间从10ms开始翻倍，最长1s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+}
+
+// 第attempt次重试前等待的时间，在[d/2, d]之间随机抖动，避免多个事务同时重试再次冲突。
+// BaseDelay不大于0时不等待；翻倍到MaxDelay后不再翻倍，避免溢出
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay) && d <= math.MaxInt64/2; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// TransactionRetry 在ctx中执行事务，遇到可重试的错误时按照policy从头重新执行f。
+// f可能被执行多次，不应包含事务之外的副作用
+func (engine *Engine) TransactionRetry(ctx context.Context, policy RetryPolicy, f TxFunc) (result interface{}, err error) {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = engine.dialect.IsRetryable
+	}
+	for attempt := 1; ; attempt++ {
+		result, err = engine.NewSession().WithContext(ctx).TransactionWith(policy.TxOptions, f)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return
+		}
+		d := policy.delay(attempt)
+		engine.logger.Warn(ctx, "retry transaction", log.Any("attempt", attempt+1), log.Any("delay", d), log.Any(log.FieldError, err))
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt+1, err, d)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(d):
+		}
+	}
+}
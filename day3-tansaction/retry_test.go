@@ -0,0 +1,71 @@
+package geeorm
+
+import (
+	"context"
+	"errors"
+	"geeorm/session"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEngine_TransactionRetry(t *testing.T) {
+	engine := OpenDB(t)
+	defer engine.Close()
+	s := engine.NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+
+	var retries []int
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		retries = append(retries, attempt)
+	}
+	attempts := 0
+	_, err := engine.TransactionRetry(context.Background(), policy, func(s *session.Session) (result interface{}, err error) {
+		attempts++
+		if _, err = s.Insert(&User{"Tom", 18}); err != nil {
+			return
+		}
+		if attempts < 3 {
+			return nil, errors.New("database is locked")
+		}
+		return
+	})
+	if err != nil || attempts != 3 || len(retries) != 2 || retries[1] != 3 {
+		t.Fatal("failed to retry transaction", err, attempts, retries)
+	}
+	if count, _ := s.Count(); count != 1 {
+		t.Fatal("expect 1 record after retries, but got", count)
+	}
+
+	attempts = 0
+	_, err = engine.TransactionRetry(context.Background(), policy, func(s *session.Session) (interface{}, error) {
+		attempts++
+		return nil, errors.New("not retryable")
+	})
+	if err == nil || attempts != 1 {
+		t.Fatal("expect non-retryable error to stop retrying", attempts)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 30 * time.Millisecond}
+	for attempt, max := range []time.Duration{10, 20, 30, 30} {
+		max *= time.Millisecond
+		if d := policy.delay(attempt + 1); d < max/2 || d > max {
+			t.Fatalf("expect delay between %v and %v, but got %v", max/2, max, d)
+		}
+	}
+	if d := (RetryPolicy{MaxDelay: time.Second}).delay(3); d != 0 {
+		t.Fatal("expect no delay without BaseDelay, but got", d)
+	}
+	//次数很大时不会溢出
+	if d := (RetryPolicy{BaseDelay: time.Second}).delay(100); d < time.Duration(math.MaxInt64/4) {
+		t.Fatal("expect delay not to overflow, but got", d)
+	}
+	if d := policy.delay(100); d < 15*time.Millisecond || d > 30*time.Millisecond {
+		t.Fatal("expect delay to be capped by MaxDelay, but got", d)
+	}
+}
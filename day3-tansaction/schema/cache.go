@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"context"
+	"geeorm/dialect"
+	"geeorm/log"
+	"reflect"
+	"sync"
+)
+
+// Cache 缓存解析好的Schema，键为结构体类型、dialect和命名规则，可以被多个goroutine同时使用
+type Cache struct {
+	schemas sync.Map   //cacheKey -> *Schema
+	logger  log.Logger //第一次解析某个类型时提示签名错误的钩子
+}
+
+type cacheKey struct {
+	typ     reflect.Type
+	dialect dialect.Dialect
+	naming  NamingStrategy
+}
+
+// NewCache 创建一个空的Schema缓存，logger为nil时使用log.Default()
+func NewCache(logger log.Logger) *Cache {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Cache{logger: logger}
+}
+
+// Parse 与Parse相同，但同一类型只解析一次，模型中签名错误的钩子也只在第一次解析时提示。
+// 返回的Schema是缓存的浅拷贝，Model为dest。
+// c为nil，或者dialect、命名规则无法作为map的键时直接解析，每次解析都会提示，c为nil时使用log.Default()
+func (c *Cache) Parse(dest interface{}, d dialect.Dialect, naming NamingStrategy) *Schema {
+	if naming == nil {
+		naming = DefaultNaming
+	}
+	if c == nil || !hashable(d) || !hashable(naming) {
+		schema := Parse(dest, d, naming)
+		c.warnHooks(schema)
+		return schema
+	}
+	key := cacheKey{
+		typ:     reflect.Indirect(reflect.ValueOf(dest)).Type(),
+		dialect: d,
+		naming:  naming,
+	}
+	v, ok := c.schemas.Load(key)
+	if !ok {
+		schema := Parse(dest, d, naming)
+		//缓存中不保留调用方的对象
+		schema.Model = nil
+		var loaded bool
+		if v, loaded = c.schemas.LoadOrStore(key, schema); !loaded {
+			c.warnHooks(schema)
+		}
+	}
+	schema := *v.(*Schema)
+	schema.Model = dest
+	return &schema
+}
+
+// 提示模型中与钩子同名但签名不正确的方法
+func (c *Cache) warnHooks(schema *Schema) {
+	logger := log.Default()
+	if c != nil {
+		logger = c.logger
+	}
+	for _, method := range schema.InvalidHooks {
+		logger.Warn(context.Background(), "method looks like a hook but has wrong signature, it will not be called",
+			log.Any("model", schema.Name), log.Any("hook", method))
+	}
+}
+
+func hashable(v interface{}) bool {
+	return v == nil || reflect.TypeOf(v).Comparable()
+}
@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCache_Parse(t *testing.T) {
+	cache := NewCache(nil)
+	u1, u2 := &User{Name: "Tom"}, &User{Name: "Sam"}
+	s1 := cache.Parse(u1, TestDial, nil)
+	s2 := cache.Parse(u2, TestDial, nil)
+	if s1.Model != u1 || s2.Model != u2 {
+		t.Fatal("expect Model to be the parsed value")
+	}
+	if &s1.Fields[0] != &s2.Fields[0] {
+		t.Fatal("expect the same type to be parsed only once")
+	}
+	if s3 := cache.Parse(u1, TestDial, Naming{TablePrefix: "t_"}); s3.Name != "t_User" {
+		t.Fatal("expect naming strategy to be part of the cache key, got", s3.Name)
+	}
+	var nilCache *Cache
+	if s := nilCache.Parse(u1, TestDial, nil); s.Name != "User" {
+		t.Fatal("failed to parse without cache")
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	cache := NewCache(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if cache.Parse(&User{}, TestDial, nil).PrimaryField == nil ||
+					cache.Parse(&Member{}, TestDial, nil).Name != "Member" {
+					t.Error("failed to parse concurrently")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// 记录钩子的方法名和对应的接口类型，由session包在init中注册
+var (
+	hooksMu sync.RWMutex
+	hooks   = map[string]reflect.Type{}
+)
+
+// RegisterHook 注册一个钩子，Parse时会检查模型中同名方法是否实现了iface
+func RegisterHook(method string, iface reflect.Type) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks[method] = iface
+}
+
+// 检查模型中与钩子同名但签名不正确的方法，返回排好序的方法名，由使用者通过自己的Logger提示
+func checkHooks(modelType reflect.Type) (invalid []string) {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	ptrType := reflect.PtrTo(modelType)
+	for method, iface := range hooks {
+		if _, ok := ptrType.MethodByName(method); ok && !ptrType.Implements(iface) {
+			invalid = append(invalid, method)
+		}
+	}
+	sort.Strings(invalid)
+	return
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy 决定结构体名到表名、字段名到列名的映射规则
+type NamingStrategy interface {
+	TableName(name string) string
+	ColumnName(name string) string
+}
+
+// Tabler 由需要自行指定表名的模型实现，返回的表名不再经过NamingStrategy处理
+type Tabler interface {
+	TableName() string
+}
+
+// 默认的命名规则：直接使用结构体名作为表名，字段名作为列名
+type defaultNaming struct{}
+
+func (defaultNaming) TableName(name string) string {
+	return name
+}
+
+func (defaultNaming) ColumnName(name string) string {
+	return name
+}
+
+// DefaultNaming 是未指定命名规则时使用的NamingStrategy
+var DefaultNaming NamingStrategy = defaultNaming{}
+
+// Naming 是可配置的命名规则，例如 Naming{SnakeCase: true, Plural: true, TablePrefix: "t_"}
+// 将UserProfile映射为表t_user_profiles，将字段UserID映射为列user_id
+type Naming struct {
+	SnakeCase   bool   //表名和列名使用snake_case
+	Plural      bool   //表名使用复数形式
+	TablePrefix string //表名前缀
+}
+
+func (n Naming) TableName(name string) string {
+	if n.SnakeCase {
+		name = toSnakeCase(name)
+	}
+	if n.Plural {
+		name = plural(name)
+	}
+	return n.TablePrefix + name
+}
+
+func (n Naming) ColumnName(name string) string {
+	if n.SnakeCase {
+		return toSnakeCase(name)
+	}
+	return name
+}
+
+// 将驼峰形式的名称转换为snake_case，连续的大写字母视为一个单词，例如UserID -> user_id，HTTPServer -> http_server
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// 按照英语的常见规则返回复数形式，不处理不规则名词
+func plural(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case lower == "":
+		return name
+	case strings.HasSuffix(lower, "s") || strings.HasSuffix(lower, "x") || strings.HasSuffix(lower, "z") ||
+		strings.HasSuffix(lower, "ch") || strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
@@ -0,0 +1,62 @@
+package schema
+
+import "testing"
+
+func TestNaming(t *testing.T) {
+	naming := Naming{SnakeCase: true, Plural: true, TablePrefix: "t_"}
+	tables := map[string]string{
+		"UserProfile": "t_user_profiles",
+		"Category":    "t_categories",
+		"Box":         "t_boxes",
+		"Day":         "t_days",
+		"HTTPServer":  "t_http_servers",
+	}
+	for name, want := range tables {
+		if got := naming.TableName(name); got != want {
+			t.Fatalf("expect table %s, but got %s", want, got)
+		}
+	}
+	columns := map[string]string{
+		"UserID":    "user_id",
+		"ID":        "id",
+		"CreatedAt": "created_at",
+		"Address2":  "address2",
+	}
+	for name, want := range columns {
+		if got := naming.ColumnName(name); got != want {
+			t.Fatalf("expect column %s, but got %s", want, got)
+		}
+	}
+}
+
+type UserProfile struct {
+	UserID   int64 `geeorm:"primary key"`
+	NickName string
+	Avatar   string `geeorm:"column:avatar_url"`
+}
+
+type LegacyOrder struct {
+	OrderID int64
+}
+
+func (LegacyOrder) TableName() string {
+	return "legacy_order"
+}
+
+func TestParse_NamingStrategy(t *testing.T) {
+	schema := Parse(&UserProfile{}, TestDial, Naming{SnakeCase: true})
+	if schema.Name != "user_profile" {
+		t.Fatal("failed to convert table name, got", schema.Name)
+	}
+	if f := schema.GetField("user_id"); f == nil || f.Name != "UserID" || schema.PrimaryField != f {
+		t.Fatal("failed to convert column name", schema.FieldNames)
+	}
+	if schema.FieldNames[1] != "nick_name" || schema.FieldNames[2] != "avatar_url" {
+		t.Fatal("expect column tag to take precedence, got", schema.FieldNames)
+	}
+
+	schema = Parse(&LegacyOrder{}, TestDial, Naming{SnakeCase: true, TablePrefix: "t_"})
+	if schema.Name != "legacy_order" || schema.FieldNames[0] != "order_id" {
+		t.Fatal("expect TableName method to take precedence, got", schema.Name, schema.FieldNames)
+	}
+}
@@ -4,53 +4,223 @@ import (
 	"geeorm/dialect"
 	"github.com/rogpeppe/godef/go/ast"
 	"reflect"
+	"strings"
 )
 
 // 代表数据库的一栏数据
 type Field struct {
-	Name string //字段名
-	Type string //类型
-	Tag  string //约束条件
+	Name       string //结构体中的字段名
+	ColumnName string //数据库中的列名，默认与Name相同，可以通过column标签修改
+	Type       string //类型
+	Tag        string //约束条件
+	Size       int    //字段长度，来自size标签，0表示未声明
+	NotNull    bool
+	Default    string //默认值，HasDefault为false时表示未设置
+	HasDefault bool
+	PrimaryKey bool
+	//自增字段，插入时零值不写入，由数据库生成后写回结构体
+	AutoIncrement bool
+	Ignore        bool //标签为-的字段不映射到数据库，不会出现在Schema.Fields中
+	//敏感字段，例如密码，记录SQL日志时绑定到该字段的值会被遮蔽
+	Sensitive bool
+	//字段在模型中的索引路径，嵌入结构体中的字段包含多级索引，用于reflect.Value.FieldByIndex
+	Index []int
+	//序列化方式，由serializer标签指定，为nil时字段值直接绑定
+	Serializer Serializer
+
+	embedded bool   //带有embedded标签的结构体字段，展开后不作为一列
+	prefix   string //展开嵌入结构体时添加到其列名前的前缀
+}
+
+// ValueOf 返回结构体v中该字段的值，索引路径上有nil指针时返回字段类型的零值
+func (f *Field) ValueOf(v reflect.Value) interface{} {
+	for i, x := range f.Index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(fieldType(v.Type().Elem(), f.Index[i:])).Interface()
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v.Interface()
+}
+
+// Addr 返回指向结构体v中该字段的指针，用于Scan。v必须可寻址，索引路径上的nil指针会被分配
+func (f *Field) Addr(v reflect.Value) interface{} {
+	for i, x := range f.Index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v.Addr().Interface()
+}
+
+// ScanDest 返回Scan时该字段使用的目标，设置了序列化方式时返回反序列化到字段的Scanner
+func (f *Field) ScanDest(v reflect.Value) interface{} {
+	dest := f.Addr(v)
+	if f.Serializer != nil {
+		return serializedScanner{serializer: f.Serializer, dest: dest}
+	}
+	return dest
+}
+
+// DBValue 返回写入数据库时该字段使用的值，设置了序列化方式时在执行时序列化
+func (f *Field) DBValue(v interface{}) interface{} {
+	if f.Serializer != nil {
+		return serializedValue{serializer: f.Serializer, value: v}
+	}
+	return v
+}
+
+func fieldType(t reflect.Type, index []int) reflect.Type {
+	for i, x := range index {
+		if i > 0 && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(x).Type
+	}
+	return t
 }
 
 type Schema struct {
-	Model      interface{}       //被映射的对象
-	Name       string            //表名
-	Fields     []*Field          //字段
-	FieldNames []string          //包含所有字段名（列名）
-	fieldMap   map[string]*Field //记录字段名和Field的映射关系，方便之后直接使用，无需遍历Field
+	Model        interface{}       //被映射的对象
+	Name         string            //表名
+	Fields       []*Field          //字段
+	FieldNames   []string          //包含所有列名
+	PrimaryField *Field            //主键字段，第一个声明为主键的字段，没有时为nil
+	InvalidHooks []string          //与钩子同名但签名不正确的方法，它们不会被调用
+	fieldMap     map[string]*Field //记录列名和Field的映射关系，方便之后直接使用，无需遍历Field
 }
 
+// 根据列名查找字段，找不到时再按结构体字段名查找
 func (schema *Schema) GetField(name string) *Field {
-	return schema.fieldMap[name]
+	if field, ok := schema.fieldMap[name]; ok {
+		return field
+	}
+	for _, field := range schema.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
 }
 
-// 将任意的对象解析为Schema实例
-func Parse(dest interface{}, d dialect.Dialect) *Schema {
+// 将任意的对象解析为Schema实例，naming为nil时使用DefaultNaming。
+// column标签指定的列名和模型TableName方法返回的表名优先于naming
+func Parse(dest interface{}, d dialect.Dialect, naming NamingStrategy) *Schema {
+	if naming == nil {
+		naming = DefaultNaming
+	}
 	modelType := reflect.Indirect(reflect.ValueOf(dest)).Type()
 	schema := &Schema{
 		Model:    dest,
-		Name:     modelType.Name(),
+		Name:     naming.TableName(modelType.Name()),
 		fieldMap: make(map[string]*Field),
 	}
-	for i := 0; i < modelType.NumField(); i++ {
+	//模型实现了TableName方法时使用其返回值，值接收者和指针接收者都可以
+	if tabler, ok := reflect.New(modelType).Interface().(Tabler); ok {
+		schema.Name = tabler.TableName()
+	}
+	schema.InvalidHooks = checkHooks(modelType)
+	schema.parseFields(modelType, d, naming, nil, "", "", map[reflect.Type]bool{modelType: true})
+	for _, field := range schema.Fields {
+		schema.FieldNames = append(schema.FieldNames, field.ColumnName)
+		if schema.PrimaryField == nil && field.PrimaryKey {
+			schema.PrimaryField = field
+		}
+	}
+	return schema
+}
 
-		p := modelType.Field(i)
-		if !p.Anonymous && ast.IsExported(p.Name) {
-			field := &Field{
-				Name: p.Name,
-				Type: d.DataTypeOf(reflect.Indirect(reflect.New(p.Type))),
+// 解析结构体typ的字段。匿名的结构体字段以及带有embedded标签的结构体字段被展开，
+// 其字段的索引路径以index开头，列名加上prefix，字段名加上namePrefix。visited用于避免循环嵌入
+func (schema *Schema) parseFields(typ reflect.Type, d dialect.Dialect, naming NamingStrategy,
+	index []int, prefix, namePrefix string, visited map[reflect.Type]bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		p := typ.Field(i)
+		if !p.Anonymous && !ast.IsExported(p.Name) {
+			continue
+		}
+		field := &Field{
+			Name:       namePrefix + p.Name,
+			ColumnName: naming.ColumnName(p.Name),
+			Index:      append(append([]int(nil), index...), i),
+		}
+		if v, ok := p.Tag.Lookup("geeorm"); ok {
+			parseTag(field, v)
+		}
+		if field.Ignore {
+			continue
+		}
+		structType := p.Type
+		if structType.Kind() == reflect.Ptr {
+			//未导出的指针无法在Scan时分配，不展开
+			if !ast.IsExported(p.Name) {
+				continue
 			}
-			if v, ok := p.Tag.Lookup("geeorm"); ok {
-				field.Tag = v
+			structType = structType.Elem()
+		}
+		if (p.Anonymous || field.embedded) && field.Serializer == nil && structType.Kind() == reflect.Struct {
+			if !visited[structType] {
+				visited[structType] = true
+				names := namePrefix
+				if !p.Anonymous {
+					names += p.Name + "."
+				}
+				schema.parseFields(structType, d, naming, field.Index, prefix+field.prefix, names, visited)
+				delete(visited, structType)
+			}
+			continue
+		}
+		if p.Anonymous && !ast.IsExported(p.Name) {
+			continue
+		}
+		field.ColumnName = prefix + field.ColumnName
+		if field.Type == "" && field.Serializer != nil {
+			field.Type = field.Serializer.DataType(d)
+		}
+		if field.Type == "" {
+			field.Type = d.DataTypeOf(reflect.Indirect(reflect.New(p.Type)), field.Size)
+			if field.AutoIncrement {
+				field.Type = d.AutoIncrementType(field.Type)
 			}
+		}
+		if field.AutoIncrement {
+			field.Tag = autoIncrementTag(field.Tag, d.AutoIncrement())
+		}
+		schema.addField(field)
+	}
+}
 
-			schema.Fields = append(schema.Fields, field)
-			schema.FieldNames = append(schema.FieldNames, p.Name)
-			schema.fieldMap[p.Name] = field
+// 将自增关键字放在PRIMARY KEY之后，SQLite要求AUTOINCREMENT紧跟PRIMARY KEY
+func autoIncrementTag(tag, keyword string) string {
+	if i := strings.Index(tag, "PRIMARY KEY"); i >= 0 {
+		i += len("PRIMARY KEY")
+		return tag[:i] + " " + keyword + tag[i:]
+	}
+	return strings.TrimSpace(tag + " " + keyword)
+}
+
+// 添加一个字段。列名重复时与Go的字段提升规则一致，保留嵌套层数较少的字段，层数相同时保留先声明的字段
+func (schema *Schema) addField(field *Field) {
+	if existing, ok := schema.fieldMap[field.ColumnName]; ok {
+		if len(field.Index) >= len(existing.Index) {
+			return
+		}
+		for i, f := range schema.Fields {
+			if f == existing {
+				schema.Fields = append(schema.Fields[:i], schema.Fields[i+1:]...)
+				break
+			}
 		}
 	}
-	return schema
+	schema.Fields = append(schema.Fields, field)
+	schema.fieldMap[field.ColumnName] = field
 }
 
 // 用于从一个目标对象中提取字段值并返回一个包含这些字段值的interface{}切片
@@ -59,8 +229,8 @@ func (schema *Schema) RecordValues(dest interface{}) []interface{} {
 	destValue := reflect.Indirect(reflect.ValueOf(dest)) //valueOf获取目标对象的反射值，Indirect方法获取目标对象的实际值，即去除指针的指向。
 	var fieldValues []interface{}                        //用于存储字段值
 	for _, field := range schema.Fields {                //遍历
-		//通过fieldByName获取目标对象中对应字段的反射值，通过interface（）方法获取该字段的具体值，然后将该字段值添加到fieldValues
-		fieldValues = append(fieldValues, destValue.FieldByName(field.Name).Interface())
+		//通过字段的索引路径获取目标对象中对应字段的值，然后将该字段值添加到fieldValues
+		fieldValues = append(fieldValues, field.DBValue(field.ValueOf(destValue)))
 	}
 	return fieldValues //包含目标中所有字段值的一个interface{}切片
 }
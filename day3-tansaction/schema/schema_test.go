@@ -2,6 +2,7 @@ package schema
 
 import (
 	"geeorm/dialect"
+	"reflect"
 	"testing"
 )
 
@@ -13,12 +14,158 @@ type User struct {
 var TestDial, _ = dialect.GetDialect("sqlite3")
 
 func TestParse(t *testing.T) {
-	schema := Parse(&User{}, TestDial)
+	schema := Parse(&User{}, TestDial, nil)
 	if schema.Name != "User" || len(schema.Fields) != 2 {
 		t.Fatal("failed to parse User struct")
 	}
 
-	if schema.GetField("Name").Tag != "PRIMARY KEY" {
+	if schema.GetField("Name").Tag != "PRIMARY KEY" || schema.PrimaryField != schema.GetField("Name") {
 		t.Fatal("failed to parse primary key")
 	}
 }
+
+func TestParse_Naming(t *testing.T) {
+	schema := Parse(&User{}, TestDial, Naming{TablePrefix: "t_"})
+	if schema.Name != "t_User" {
+		t.Fatal("failed to apply naming strategy, got", schema.Name)
+	}
+}
+
+type validator interface {
+	Validate() error
+}
+
+type BadHook struct {
+	Name string
+}
+
+func (b *BadHook) Validate() {}
+
+func TestParse_CheckHooks(t *testing.T) {
+	RegisterHook("Validate", reflect.TypeOf((*validator)(nil)).Elem())
+	defer func() {
+		hooksMu.Lock()
+		delete(hooks, "Validate")
+		hooksMu.Unlock()
+	}()
+	if invalid := checkHooks(reflect.TypeOf(BadHook{})); !reflect.DeepEqual(invalid, []string{"Validate"}) {
+		t.Fatal("failed to detect hook with wrong signature, got", invalid)
+	}
+	if invalid := checkHooks(reflect.TypeOf(User{})); len(invalid) != 0 {
+		t.Fatal("expect no invalid hooks, but got", invalid)
+	}
+}
+
+type Account struct {
+	Name     string `geeorm:"PRIMARY KEY"`
+	Password string `geeorm:"NOT NULL;sensitive"`
+}
+
+func TestParse_Sensitive(t *testing.T) {
+	schema := Parse(&Account{}, TestDial, nil)
+	if schema.GetField("Name").Sensitive {
+		t.Fatal("expect Name not to be sensitive")
+	}
+	if f := schema.GetField("Password"); !f.Sensitive || f.Tag != "NOT NULL" {
+		t.Fatalf("failed to parse sensitive tag, got %+v", f)
+	}
+}
+
+type Member struct {
+	ID       int64  `geeorm:"primary key"`
+	Name     string `geeorm:"column:user_name;size:64;not null"`
+	Score    int    `geeorm:"default:0"`
+	Password string `geeorm:"-"`
+	Remark   string `geeorm:"column:remark;CHECK (remark <> '')"`
+}
+
+func TestParse_Tags(t *testing.T) {
+	schema := Parse(&Member{}, TestDial, nil)
+	if !reflect.DeepEqual(schema.FieldNames, []string{"ID", "user_name", "Score", "remark"}) {
+		t.Fatal("unexpected columns", schema.FieldNames)
+	}
+	if schema.PrimaryField != schema.GetField("ID") || schema.GetField("ID").Tag != "PRIMARY KEY" {
+		t.Fatal("failed to parse primary key")
+	}
+	name := schema.GetField("user_name")
+	if name == nil || name != schema.GetField("Name") || name.Name != "Name" || name.Size != 64 ||
+		!name.NotNull || name.Tag != "NOT NULL" {
+		t.Fatalf("failed to parse column tag, got %+v", name)
+	}
+	if score := schema.GetField("Score"); !score.HasDefault || score.Default != "0" || score.Tag != "DEFAULT 0" {
+		t.Fatalf("failed to parse default tag, got %+v", score)
+	}
+	if schema.GetField("Password") != nil {
+		t.Fatal("expect Password to be ignored")
+	}
+	if remark := schema.GetField("remark"); remark.Tag != "CHECK (remark <> '')" {
+		t.Fatalf("expect raw constraint to be kept, got %+v", remark)
+	}
+}
+
+type BaseModel struct {
+	ID        int64 `geeorm:"primary key"`
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type Address struct {
+	City   string
+	Street string `geeorm:"column:street_name"`
+}
+
+type Audit struct {
+	Operator string
+}
+
+type Shop struct {
+	BaseModel
+	*Audit
+	Name      string
+	UpdatedAt string  //覆盖BaseModel中的同名字段
+	Address   Address `geeorm:"embedded;prefix:addr_"`
+	Backup    Address `geeorm:"-"`
+}
+
+func TestParse_Embedded(t *testing.T) {
+	schema := Parse(&Shop{}, TestDial, nil)
+	want := []string{"ID", "CreatedAt", "Operator", "Name", "UpdatedAt", "addr_City", "addr_street_name"}
+	if !reflect.DeepEqual(schema.FieldNames, want) {
+		t.Fatal("unexpected columns", schema.FieldNames)
+	}
+	if schema.PrimaryField != schema.GetField("ID") || !reflect.DeepEqual(schema.PrimaryField.Index, []int{0, 0}) {
+		t.Fatal("failed to parse embedded primary key")
+	}
+	if f := schema.GetField("addr_City"); f.Name != "Address.City" || !reflect.DeepEqual(f.Index, []int{4, 0}) {
+		t.Fatalf("failed to parse embedded field, got %+v", f)
+	}
+	if f := schema.GetField("UpdatedAt"); f.Type != "text" {
+		t.Fatal("expect outer field to shadow embedded field")
+	}
+
+	shop := &Shop{BaseModel: BaseModel{ID: 1}, Name: "gee", Address: Address{City: "Beijing"}}
+	values := schema.RecordValues(shop)
+	if values[0] != int64(1) || values[2] != "" || values[5] != "Beijing" {
+		t.Fatal("unexpected record values", values)
+	}
+}
+
+type Ticket struct {
+	ID    int64 `geeorm:"primaryKey;autoIncrement"`
+	Title string
+}
+
+func TestParse_AutoIncrement(t *testing.T) {
+	cases := map[string]string{
+		"sqlite3":  "integer PRIMARY KEY AUTOINCREMENT",
+		"mysql":    "bigint PRIMARY KEY AUTO_INCREMENT",
+		"postgres": "bigint PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY",
+	}
+	for name, want := range cases {
+		d, _ := dialect.GetDialect(name)
+		f := Parse(&Ticket{}, d, nil).PrimaryField
+		if f == nil || !f.AutoIncrement || f.Type+" "+f.Tag != want {
+			t.Fatalf("%s: expect %s, but got %+v", name, want, f)
+		}
+	}
+}
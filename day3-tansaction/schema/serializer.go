@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"geeorm/dialect"
+	"reflect"
+	"sync"
+)
+
+// Serializer 负责把无法直接绑定的字段值（结构体、map、切片等）序列化后存入一列，读取时再反序列化
+type Serializer interface {
+	//将字段的值序列化为写入数据库的值
+	Value(v interface{}) (driver.Value, error)
+	//将从数据库读出的src反序列化到dest，dest是指向字段的指针，src为nil时dest被置为零值
+	Scan(dest interface{}, src interface{}) error
+	//存储序列化结果的列类型
+	DataType(d dialect.Dialect) string
+}
+
+var (
+	serializersMu sync.RWMutex
+	serializers   = map[string]Serializer{
+		"json": JSONSerializer{},
+		"gob":  GobSerializer{},
+	}
+)
+
+// RegisterSerializer 注册一个序列化方式，之后可以通过 geeorm:"serializer:name" 使用
+func RegisterSerializer(name string, s Serializer) {
+	serializersMu.Lock()
+	defer serializersMu.Unlock()
+	serializers[name] = s
+}
+
+// GetSerializer 返回名为name的序列化方式
+func GetSerializer(name string) (s Serializer, ok bool) {
+	serializersMu.RLock()
+	defer serializersMu.RUnlock()
+	s, ok = serializers[name]
+	return
+}
+
+// JSONSerializer 以JSON文本存储字段，列类型由dialect决定
+type JSONSerializer struct{}
+
+func (JSONSerializer) Value(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (JSONSerializer) Scan(dest interface{}, src interface{}) error {
+	data, ok, err := bytesOf(dest, src)
+	if !ok {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (JSONSerializer) DataType(d dialect.Dialect) string {
+	return d.JSONType()
+}
+
+// GobSerializer 以gob编码的二进制存储字段，只能在Go程序之间读取
+type GobSerializer struct{}
+
+func (GobSerializer) Value(v interface{}) (driver.Value, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobSerializer) Scan(dest interface{}, src interface{}) error {
+	data, ok, err := bytesOf(dest, src)
+	if !ok {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dest)
+}
+
+func (GobSerializer) DataType(d dialect.Dialect) string {
+	return d.DataTypeOf(reflect.ValueOf([]byte(nil)), 0)
+}
+
+// 取出src中的字节，src为NULL时把dest置为零值并返回ok为false
+func bytesOf(dest interface{}, src interface{}) (data []byte, ok bool, err error) {
+	switch v := src.(type) {
+	case nil:
+		rv := reflect.ValueOf(dest).Elem()
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil, false, nil
+	case []byte:
+		return v, true, nil
+	case string:
+		return []byte(v), true, nil
+	}
+	return nil, false, fmt.Errorf("can not deserialize %T", src)
+}
+
+// 写入时延迟序列化的字段值，序列化的错误由database/sql在执行时返回
+type serializedValue struct {
+	serializer Serializer
+	value      interface{}
+}
+
+func (v serializedValue) Value() (driver.Value, error) {
+	return v.serializer.Value(v.value)
+}
+
+// 日志中显示序列化后的值
+func (v serializedValue) String() string {
+	dv, err := v.Value()
+	if err != nil {
+		return fmt.Sprintf("!serialize(%v)", err)
+	}
+	if b, ok := dv.([]byte); ok {
+		return fmt.Sprintf("%x", b)
+	}
+	return fmt.Sprint(dv)
+}
+
+// 读取时反序列化到字段的Scanner
+type serializedScanner struct {
+	serializer Serializer
+	dest       interface{}
+}
+
+func (s serializedScanner) Scan(src interface{}) error {
+	return s.serializer.Scan(s.dest, src)
+}
+
+// JSON 返回v序列化为JSON后的值，用于在Where中与JSON列比较，例如 Where("Tags = ?", schema.JSON(tags))
+func JSON(v interface{}) driver.Valuer {
+	return serializedValue{serializer: JSONSerializer{}, value: v}
+}
+
+// Gob 返回v使用gob编码后的值，用于在Where中与gob列比较
+func Gob(v interface{}) driver.Valuer {
+	return serializedValue{serializer: GobSerializer{}, value: v}
+}
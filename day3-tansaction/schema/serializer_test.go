@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type Settings struct {
+	Theme string
+	Size  int
+}
+
+type Preference struct {
+	ID       int64             `geeorm:"primary key"`
+	Tags     []string          `geeorm:"serializer:json"`
+	Labels   map[string]string `geeorm:"serializer:json"`
+	Settings Settings          `geeorm:"serializer:gob"`
+}
+
+func TestParse_Serializer(t *testing.T) {
+	schema := Parse(&Preference{}, TestDial, nil)
+	if !reflect.DeepEqual(schema.FieldNames, []string{"ID", "Tags", "Labels", "Settings"}) {
+		t.Fatal("unexpected columns", schema.FieldNames)
+	}
+	if schema.GetField("Tags").Type != "text" || schema.GetField("Settings").Type != "blob" {
+		t.Fatal("unexpected column types")
+	}
+
+	values := schema.RecordValues(&Preference{Tags: []string{"a", "b"}, Labels: map[string]string{"k": "v"}})
+	tags, err := values[1].(driver.Valuer).Value()
+	if err != nil || tags != `["a","b"]` {
+		t.Fatal("failed to serialize json, got", tags, err)
+	}
+}
+
+func TestSerializer_Scan(t *testing.T) {
+	var tags []string
+	if err := (JSONSerializer{}).Scan(&tags, []byte(`["x"]`)); err != nil || !reflect.DeepEqual(tags, []string{"x"}) {
+		t.Fatal("failed to scan json", tags, err)
+	}
+	if err := (JSONSerializer{}).Scan(&tags, nil); err != nil || tags != nil {
+		t.Fatal("expect NULL to reset the field", tags, err)
+	}
+
+	in := Settings{Theme: "dark", Size: 12}
+	b, err := Gob(in).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Settings
+	if err = (GobSerializer{}).Scan(&out, b); err != nil || out != in {
+		t.Fatal("failed to scan gob", out, err)
+	}
+}
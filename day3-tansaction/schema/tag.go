@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 解析geeorm标签，多个设置之间用分号分隔，例如 geeorm:"column:user_name;size:64;not null;default:0"。
+//
+//	"-"           不映射该字段
+//	column:name   列名
+//	size:n        字段长度
+//	type:t        列类型，覆盖dialect根据Go类型推断的类型
+//	serializer:s  序列化方式，例如json、gob，用于结构体、map、切片等字段
+//	not null      NOT NULL约束
+//	default:v     默认值
+//	primary key   主键，也可以写作primaryKey
+//	autoIncrement 自增，插入零值时由数据库生成并写回结构体
+//	sensitive     敏感字段，日志中遮蔽其参数
+//	embedded      展开结构体字段，匿名的结构体字段总是被展开
+//	prefix:p      展开结构体时添加到其列名前的前缀
+//
+// 其余内容作为原始的约束条件原样保留。NOT NULL、DEFAULT、PRIMARY KEY与原始约束按照
+// 在标签中出现的顺序拼接为field.Tag，用于建表
+func parseTag(field *Field, tag string) {
+	if strings.TrimSpace(tag) == "-" {
+		field.Ignore = true
+		return
+	}
+	var constraints []string
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(part, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch {
+		case hasValue && key == "column":
+			field.ColumnName = value
+		case hasValue && key == "size":
+			field.Size, _ = strconv.Atoi(value)
+		case hasValue && key == "type":
+			field.Type = value
+		case hasValue && key == "serializer":
+			serializer, ok := GetSerializer(value)
+			if !ok {
+				panic(fmt.Sprintf("serializer %s Not Found", value))
+			}
+			field.Serializer = serializer
+		case hasValue && key == "default":
+			field.Default, field.HasDefault = value, true
+			constraints = append(constraints, "DEFAULT "+value)
+		case key == "not null":
+			field.NotNull = true
+			constraints = append(constraints, "NOT NULL")
+		case key == "primary key" || key == "primarykey":
+			field.PrimaryKey = true
+			constraints = append(constraints, "PRIMARY KEY")
+		case key == "autoincrement" || key == "auto increment":
+			field.AutoIncrement = true
+		case key == "sensitive":
+			field.Sensitive = true
+		case key == "embedded":
+			field.embedded = true
+		case hasValue && key == "prefix":
+			field.prefix = value
+		default:
+			//兼容直接书写约束条件的旧标签，例如 geeorm:"PRIMARY KEY AUTO_INCREMENT"
+			if strings.Contains(strings.ToUpper(part), "PRIMARY KEY") {
+				field.PrimaryKey = true
+			}
+			constraints = append(constraints, part)
+		}
+	}
+	field.Tag = strings.Join(constraints, " ")
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"fmt"
+	"geeorm/schema"
+	"sync"
+)
+
+// Operation 表示一次SQL执行所属的操作类型，回调按操作类型分别注册
+type Operation int
+
+const (
+	OpRaw Operation = iota //通过Raw直接执行的SQL，以及建表等操作
+	OpCreate
+	OpQuery
+	OpUpdate
+	OpDelete
+)
+
+// Scope 是传递给回调的上下文，before回调可以修改SQL和Vars，after回调可以读取执行结果
+type Scope struct {
+	Session      *Session
+	Operation    Operation
+	Table        *schema.Schema //OpRaw时可能为nil
+	SQL          string         //已经替换为dialect占位符的最终SQL
+	Vars         []interface{}
+	RowsAffected int64 //仅对Exec有效
+	Error        error //SQL执行的错误，仅after回调可见
+
+	sensitive []bool //Vars中对应位置的参数是否敏感，用于日志遮蔽
+}
+
+// CallbackFunc 回调函数，before回调返回错误时SQL不会被执行，after回调的错误会返回给调用方
+type CallbackFunc func(scope *Scope) error
+
+type callback struct {
+	name  string
+	after bool
+	fn    CallbackFunc
+}
+
+// Callbacks 是Engine级别的回调注册表，对所有模型生效，同一Operation下回调名称唯一，
+// 同一阶段的回调按注册顺序执行
+type Callbacks struct {
+	mu        sync.RWMutex
+	callbacks map[Operation][]*callback
+}
+
+func NewCallbacks() *Callbacks {
+	return &Callbacks{callbacks: make(map[Operation][]*callback)}
+}
+
+// Before 注册一个在SQL执行前调用的回调
+func (c *Callbacks) Before(op Operation, name string, fn CallbackFunc) error {
+	return c.register(op, &callback{name: name, fn: fn})
+}
+
+// After 注册一个在SQL执行后调用的回调
+func (c *Callbacks) After(op Operation, name string, fn CallbackFunc) error {
+	return c.register(op, &callback{name: name, after: true, fn: fn})
+}
+
+func (c *Callbacks) register(op Operation, cb *callback) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index(op, cb.name) >= 0 {
+		return fmt.Errorf("callback %s already registered", cb.name)
+	}
+	c.callbacks[op] = append(c.callbacks[op], cb)
+	return nil
+}
+
+// Replace 替换已注册的同名回调，执行阶段和顺序保持不变
+func (c *Callbacks) Replace(op Operation, name string, fn CallbackFunc) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(op, name)
+	if i < 0 {
+		return fmt.Errorf("callback %s Not Found", name)
+	}
+	cbs := c.copy(op)
+	cbs[i] = &callback{name: name, after: cbs[i].after, fn: fn}
+	c.callbacks[op] = cbs
+	return nil
+}
+
+// Remove 删除已注册的同名回调
+func (c *Callbacks) Remove(op Operation, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.index(op, name)
+	if i < 0 {
+		return fmt.Errorf("callback %s Not Found", name)
+	}
+	cbs := c.copy(op)
+	c.callbacks[op] = append(cbs[:i], cbs[i+1:]...)
+	return nil
+}
+
+// 修改时复制一份切片，正在执行的回调列表不受影响
+func (c *Callbacks) copy(op Operation) []*callback {
+	return append([]*callback(nil), c.callbacks[op]...)
+}
+
+func (c *Callbacks) index(op Operation, name string) int {
+	for i, cb := range c.callbacks[op] {
+		if cb.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// 依次调用scope.Operation下指定阶段的回调，遇到错误立即返回
+func (c *Callbacks) run(scope *Scope, after bool) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	cbs := c.callbacks[scope.Operation]
+	c.mu.RUnlock()
+	for _, cb := range cbs {
+		if cb.after != after {
+			continue
+		}
+		if err := cb.fn(scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCallbacks_Register(t *testing.T) {
+	cb := NewCallbacks()
+	var calls []string
+	record := func(name string) CallbackFunc {
+		return func(scope *Scope) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	_ = cb.Before(OpQuery, "a", record("a"))
+	_ = cb.Before(OpQuery, "b", record("b"))
+	_ = cb.After(OpQuery, "c", record("c"))
+	if err := cb.Before(OpQuery, "a", record("a")); err == nil {
+		t.Fatal("expect error for duplicated callback name")
+	}
+	_ = cb.Replace(OpQuery, "a", record("a2"))
+	_ = cb.Remove(OpQuery, "b")
+	if err := cb.Remove(OpQuery, "b"); err == nil {
+		t.Fatal("expect error for removing unknown callback")
+	}
+
+	scope := &Scope{Operation: OpQuery}
+	_ = cb.run(scope, false)
+	_ = cb.run(scope, true)
+	if !reflect.DeepEqual(calls, []string{"a2", "c"}) {
+		t.Fatal("failed to run callbacks in order, got", calls)
+	}
+}
+
+func TestSession_Callbacks(t *testing.T) {
+	cb := NewCallbacks()
+	var scopes []Scope
+	_ = cb.After(OpCreate, "audit", func(scope *Scope) error {
+		scopes = append(scopes, *scope)
+		return nil
+	})
+	_ = cb.Before(OpDelete, "forbid", func(scope *Scope) error {
+		return errors.New("delete is forbidden")
+	})
+	s := New(TestDB, TestDial, WithCallbacks(cb)).Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, _ = s.Insert(user1, user2)
+	if len(scopes) != 1 || scopes[0].Table.Name != "User" || scopes[0].RowsAffected != 2 ||
+		scopes[0].SQL != `INSERT INTO "User" ("Name","Age") VALUES (?, ?), (?, ?) ` {
+		t.Fatal("failed to call after create callback", scopes)
+	}
+	if _, err := s.Delete(); err == nil {
+		t.Fatal("expect before delete callback to abort")
+	}
+	if count, _ := s.Count(); count != 2 {
+		t.Fatal("expect 2 records, but got", count)
+	}
+}
+
+func TestSession_QueryRowCallbackError(t *testing.T) {
+	cb := NewCallbacks()
+	after := 0
+	_ = cb.Before(OpRaw, "forbid", func(scope *Scope) error {
+		return errors.New("raw sql is forbidden")
+	})
+	_ = cb.After(OpRaw, "count", func(scope *Scope) error {
+		after++
+		return nil
+	})
+	s := New(TestDB, TestDial, WithCallbacks(cb))
+	var n int
+	if err := s.Raw("SELECT 1").QueryRow().Scan(&n); !errors.Is(err, context.Canceled) || n != 0 {
+		t.Fatal("expect query to be skipped, but got", err, n)
+	}
+	if after != 0 {
+		t.Fatal("expect after callbacks not to run, but got", after)
+	}
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSession_Concurrent(t *testing.T) {
+	s := testRecordInit(t)
+	accounts := s.Model(&Account{})
+	_ = accounts.DropTable()
+	if err := accounts.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 80)
+	for i := 0; i < 20; i++ {
+		wg.Add(4)
+		//在共享的会话上切换模型，Model返回副本，不会影响其他goroutine
+		go func() {
+			defer wg.Done()
+			if count, err := s.Model(&User{}).Count(); err != nil || count != 2 {
+				errs <- fmt.Errorf("failed to count users concurrently, got %d %v", count, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if count, err := s.Model(&Account{}).Count(); err != nil || count != 0 {
+				errs <- fmt.Errorf("failed to count accounts concurrently, got %d %v", count, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var users []User
+			if err := s.Where("Name = ?", "Tom").Find(&users); err != nil || len(users) != 1 {
+				errs <- errFailed("where", users, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var users []User
+			if err := s.OrderBy("Age DESC").Limit(1).Find(&users); err != nil || len(users) != 1 || users[0].Name != "Sam" {
+				errs <- errFailed("order by", users, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_ChainDoesNotLeak(t *testing.T) {
+	s := testRecordInit(t)
+	_ = s.Where("Name = ?", "Tom")
+	if count, err := s.Count(); err != nil || count != 2 {
+		t.Fatal("expect unfinished chain not to affect next query, got", count, err)
+	}
+	limited := s.Limit(1)
+	var users []User
+	if err := limited.Find(&users); err != nil || len(users) != 1 {
+		t.Fatal("failed to query with limit", err)
+	}
+	users = nil
+	if err := limited.Find(&users); err != nil || len(users) != 1 {
+		t.Fatal("expect chain to be reusable, got", len(users), err)
+	}
+}
+
+func errFailed(name string, users []User, err error) error {
+	return fmt.Errorf("failed to query %s concurrently, got %v %v", name, users, err)
+}
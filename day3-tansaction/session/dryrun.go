@@ -0,0 +1,85 @@
+package session
+
+import (
+	"errors"
+	"geeorm/clause"
+	"strings"
+	"sync"
+)
+
+// ErrDryRun 在DryRun模式下由QueryRows返回，表示SQL只被记录而没有执行
+var ErrDryRun = errors.New("dry run: sql is not executed")
+
+// Statement 是DryRun模式下记录的一条SQL，SQL中的占位符已经替换为dialect的形式
+type Statement struct {
+	SQL  string
+	Vars []interface{}
+
+	sensitive []bool
+	literal   func(v interface{}) string //dialect生成字面量的方法
+}
+
+// ToSQL 返回按照dialect的写法把参数内联到SQL中的结果，敏感参数被遮蔽，只用于阅读和调试，不应被执行
+func (st Statement) ToSQL() string {
+	vars := st.Vars
+	if st.sensitive != nil {
+		vars = append([]interface{}(nil), st.Vars...)
+		for i := range vars {
+			if i < len(st.sensitive) && st.sensitive[i] {
+				vars[i] = redacted
+			}
+		}
+	}
+	return clause.Interpolate(st.SQL, vars, st.literal)
+}
+
+// 同一次DryRun派生出的会话共享记录的语句
+type dryRunState struct {
+	mu    sync.Mutex
+	stmts []Statement
+}
+
+func (d *dryRunState) record(scope *Scope) {
+	st := Statement{SQL: strings.TrimSpace(scope.SQL), Vars: scope.Vars, sensitive: scope.sensitive}
+	if scope.Session.dialect != nil {
+		st.literal = scope.Session.dialect.Literal
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stmts = append(d.stmts, st)
+}
+
+// DryRun 返回一个只构造SQL而不访问数据库的会话副本，之后执行的SQL通过Statements取得。
+// Before钩子和before回调照常执行，以便得到与真实执行相同的SQL；After钩子和after回调不执行。
+// Insert/Update/Delete返回0，Find不填充结果，Count返回0，QueryRows返回ErrDryRun。
+// Transaction直接执行事务函数，不会开启事务；Begin、Commit和Rollback仍然会访问数据库，不应在DryRun模式下调用
+func (s *Session) DryRun() *Session {
+	c := s.clone()
+	c.dryRun = &dryRunState{}
+	return c
+}
+
+// Statements 返回DryRun模式下按执行顺序记录的SQL，不在DryRun模式时返回nil
+func (s *Session) Statements() []Statement {
+	if s.dryRun == nil {
+		return nil
+	}
+	s.dryRun.mu.Lock()
+	defer s.dryRun.mu.Unlock()
+	return append([]Statement(nil), s.dryRun.stmts...)
+}
+
+// ToSQL 返回DryRun模式下最近一条SQL内联参数后的结果，没有记录时返回空字符串
+func (s *Session) ToSQL() string {
+	stmts := s.Statements()
+	if len(stmts) == 0 {
+		return ""
+	}
+	return stmts[len(stmts)-1].ToSQL()
+}
+
+// DryRun模式下Exec返回的结果
+type dryRunResult struct{}
+
+func (dryRunResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryRunResult) RowsAffected() (int64, error) { return 0, nil }
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"errors"
+	"geeorm/dialect"
+	"strings"
+	"testing"
+)
+
+func TestSession_DryRun(t *testing.T) {
+	s := testRecordInit(t)
+	dry := s.DryRun()
+
+	if affected, err := dry.Insert(user3); err != nil || affected != 0 {
+		t.Fatal("failed to dry run insert", err)
+	}
+	if got := dry.ToSQL(); got != `INSERT INTO "User" ("Name","Age") VALUES ('Jack', 25)` {
+		t.Fatal("unexpected sql", got)
+	}
+	var users []User
+	if err := dry.Where("Name = ?", "Tom").Limit(1).Find(&users); err != nil || len(users) != 0 {
+		t.Fatal("failed to dry run find", err)
+	}
+	if _, err := dry.Where("Name = ?", "Tom").Update("Age", 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dry.Where("Name = ?", "Tom").Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := dry.Count(); err != nil || count != 0 {
+		t.Fatal("failed to dry run count", err)
+	}
+	if _, err := dry.Raw("SELECT 1").QueryRows(); !errors.Is(err, ErrDryRun) {
+		t.Fatal("expect ErrDryRun, but got", err)
+	}
+
+	stmts := dry.Statements()
+	want := []string{
+		`INSERT INTO "User" ("Name","Age") VALUES ('Jack', 25)`,
+		`SELECT "Name","Age" FROM "User" WHERE Name = 'Tom' LIMIT 1`,
+		`UPDATE "User" SET "Age" = 30 WHERE Name = 'Tom'`,
+		`DELETE FROM "User" WHERE Name = 'Tom'`,
+		`SELECT count(*) FROM "User"`,
+		"SELECT 1",
+	}
+	if len(stmts) != len(want) {
+		t.Fatalf("expect %d statements, but got %d", len(want), len(stmts))
+	}
+	for i, stmt := range stmts {
+		if got := stmt.ToSQL(); got != want[i] {
+			t.Fatalf("expect %q, but got %q", want[i], got)
+		}
+	}
+
+	//DryRun不会访问数据库，原会话也不受影响
+	if count, _ := s.Count(); count != 2 {
+		t.Fatal("expect dry run not to touch the database, but got", count)
+	}
+	if s.Statements() != nil {
+		t.Fatal("expect original session not to be in dry run mode")
+	}
+}
+
+func TestSession_DryRunPlaceholder(t *testing.T) {
+	postgres, _ := dialect.GetDialect("postgres")
+	dry := New(TestDB, postgres).Model(&Credential{}).DryRun()
+	if _, err := dry.Where("Name = ?", "Tom").Update("Password", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	var credentials []Credential
+	if err := dry.Where("Password = ?", "secret").Find(&credentials); err != nil {
+		t.Fatal(err)
+	}
+	if got := dry.Statements()[1].ToSQL(); !strings.HasSuffix(got, `WHERE Password = '***'`) {
+		t.Fatal("expect sensitive where arg to be masked, got", got)
+	}
+	stmt := dry.Statements()[0]
+	if stmt.SQL != `UPDATE "Credential" SET "Password" = $1 WHERE Name = $2` {
+		t.Fatal("unexpected sql", stmt.SQL)
+	}
+	if got := stmt.ToSQL(); got != `UPDATE "Credential" SET "Password" = '***' WHERE Name = 'Tom'` {
+		t.Fatal("unexpected sql", got)
+	}
+}
+
+func TestSession_DryRunQuote(t *testing.T) {
+	mysql, _ := dialect.GetDialect("mysql")
+	dry := New(TestDB, mysql).Model(&User{}).DryRun()
+	var users []User
+	_ = dry.Find(&users)
+	_, _ = dry.Count()
+	want := []string{"SELECT `Name`,`Age` FROM `User`", "SELECT count(*) FROM `User`"}
+	if len(dry.Statements()) != len(want) {
+		t.Fatal("unexpected statements", dry.Statements())
+	}
+	for i, stmt := range dry.Statements() {
+		if stmt.SQL != want[i] {
+			t.Fatalf("expect %s, but got %s", want[i], stmt.SQL)
+		}
+	}
+}
+
+func TestSession_DryRunTransaction(t *testing.T) {
+	postgres, _ := dialect.GetDialect("postgres")
+	dry := New(TestDB, postgres).Model(&Credential{}).DryRun()
+	_, err := dry.Transaction(func(s *Session) (interface{}, error) {
+		if s.TxDepth() != 0 {
+			t.Fatal("expect no transaction in dry run")
+		}
+		return s.Insert(&Credential{Name: "Tom", Avatar: []byte("ab")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `INSERT INTO "Credential" ("Name","Password","Avatar") VALUES ('Tom', '***', '\x6162')`
+	if stmts := dry.Statements(); len(stmts) != 1 || stmts[0].ToSQL() != want {
+		t.Fatalf("expect %s, but got %v", want, stmts)
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"geeorm/log"
+	"geeorm/schema"
 	"reflect"
 )
 
@@ -16,19 +17,95 @@ const (
 	AfterInsert  = "AfterInsert"
 )
 
-func (s *Session) CallMethod(method string, value interface{}) {
+// 钩子接口，模型实现对应的接口即可在操作前后被调用，
+// 可以通过 var _ session.BeforeInserter = (*User)(nil) 在编译期检查是否实现
+type BeforeQueryer interface {
+	BeforeQuery(s *Session) error
+}
+
+type AfterQueryer interface {
+	AfterQuery(s *Session) error
+}
+
+type BeforeUpdater interface {
+	BeforeUpdate(s *Session) error
+}
+
+type AfterUpdater interface {
+	AfterUpdate(s *Session) error
+}
+
+type BeforeDeleter interface {
+	BeforeDelete(s *Session) error
+}
+
+type AfterDeleter interface {
+	AfterDelete(s *Session) error
+}
+
+type BeforeInserter interface {
+	BeforeInsert(s *Session) error
+}
 
-	fm := reflect.ValueOf(s.RefTable().Model).MethodByName(method)
-	if value != nil {
-		fm = reflect.ValueOf(value).MethodByName(method)
+type AfterInserter interface {
+	AfterInsert(s *Session) error
+}
+
+// 向schema注册钩子的方法名和接口，schema.Parse据此检查签名错误的钩子方法
+func init() {
+	schema.RegisterHook(BeforeQuery, reflect.TypeOf((*BeforeQueryer)(nil)).Elem())
+	schema.RegisterHook(AfterQuery, reflect.TypeOf((*AfterQueryer)(nil)).Elem())
+	schema.RegisterHook(BeforeUpdate, reflect.TypeOf((*BeforeUpdater)(nil)).Elem())
+	schema.RegisterHook(AfterUpdate, reflect.TypeOf((*AfterUpdater)(nil)).Elem())
+	schema.RegisterHook(BeforeDelete, reflect.TypeOf((*BeforeDeleter)(nil)).Elem())
+	schema.RegisterHook(AfterDelete, reflect.TypeOf((*AfterDeleter)(nil)).Elem())
+	schema.RegisterHook(BeforeInsert, reflect.TypeOf((*BeforeInserter)(nil)).Elem())
+	schema.RegisterHook(AfterInsert, reflect.TypeOf((*AfterInserter)(nil)).Elem())
+}
+
+// 调用钩子函数并返回钩子的错误，Before*钩子返回错误时操作会被终止
+// 通过类型断言判断value是否实现了对应的钩子接口，value为nil时使用RefTable().Model
+func (s *Session) CallMethod(method string, value interface{}) error {
+	if value == nil {
+		value = s.RefTable().Model
 	}
-	param := []reflect.Value{reflect.ValueOf(s)}
-	if fm.IsValid() {
-		if v := fm.Call(param); len(v) > 0 {
-			if err, ok := v[0].Interface().(error); ok {
-				log.Error(err)
-			}
+	var err error
+	switch method {
+	case BeforeQuery:
+		if i, ok := value.(BeforeQueryer); ok {
+			err = i.BeforeQuery(s)
+		}
+	case AfterQuery:
+		if i, ok := value.(AfterQueryer); ok {
+			err = i.AfterQuery(s)
+		}
+	case BeforeUpdate:
+		if i, ok := value.(BeforeUpdater); ok {
+			err = i.BeforeUpdate(s)
 		}
+	case AfterUpdate:
+		if i, ok := value.(AfterUpdater); ok {
+			err = i.AfterUpdate(s)
+		}
+	case BeforeDelete:
+		if i, ok := value.(BeforeDeleter); ok {
+			err = i.BeforeDelete(s)
+		}
+	case AfterDelete:
+		if i, ok := value.(AfterDeleter); ok {
+			err = i.AfterDelete(s)
+		}
+	case BeforeInsert:
+		if i, ok := value.(BeforeInserter); ok {
+			err = i.BeforeInsert(s)
+		}
+	case AfterInsert:
+		if i, ok := value.(AfterInserter); ok {
+			err = i.AfterInsert(s)
+		}
+	}
+	if err != nil {
+		s.Logger().Error(s.Context(), "hook failed", log.Any("hook", method), log.Any(log.FieldError, err))
 	}
-	return
+	return err
 }
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"errors"
 	"geeorm/log"
 	"testing"
 )
@@ -10,6 +11,13 @@ type Account struct {
 	Password string
 }
 
+var (
+	_ BeforeInserter = (*Account)(nil)
+	_ AfterQueryer   = (*Account)(nil)
+	_ BeforeInserter = (*Member)(nil)
+	_ BeforeDeleter  = (*Member)(nil)
+)
+
 func (account *Account) BeforeInsert(s *Session) error {
 	log.Info("before inert", account)
 	account.ID += 1000
@@ -35,3 +43,104 @@ func TestSession_CallMethod(t *testing.T) {
 		t.Fatal("Failed to call hooks after query, got", u)
 	}
 }
+
+type Member struct {
+	Name string `geeorm:"PRIMARY KEY"`
+	Age  int
+}
+
+func (m *Member) BeforeInsert(s *Session) error {
+	if m.Age < 0 {
+		return errors.New("age must not be negative")
+	}
+	return nil
+}
+
+func (m *Member) BeforeDelete(s *Session) error {
+	return errors.New("member can not be deleted")
+}
+
+func TestSession_HookError(t *testing.T) {
+	s := NewSession().Model(&Member{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	if _, err := s.Insert(&Member{"Tom", -1}); err == nil {
+		t.Fatal("expect BeforeInsert to abort insert")
+	}
+	if count, _ := s.Count(); count != 0 {
+		t.Fatal("expect no record inserted, but got", count)
+	}
+	_, _ = s.Insert(&Member{"Tom", 18})
+	if _, err := s.Where("Name = ?", "Tom").Delete(); err == nil {
+		t.Fatal("expect BeforeDelete to abort delete")
+	}
+	if count, _ := s.Count(); count != 1 {
+		t.Fatal("expect record kept, but got", count)
+	}
+}
+
+type Secret struct {
+	Name string `geeorm:"PRIMARY KEY"`
+}
+
+func (secret *Secret) BeforeQuery(s *Session) error {
+	return errors.New("secret can not be queried")
+}
+
+func TestSession_BeforeQueryError(t *testing.T) {
+	s := NewSession().Model(&Secret{})
+	var secrets []Secret
+	if err := s.Find(&secrets); err == nil || err.Error() != "secret can not be queried" {
+		t.Fatal("expect BeforeQuery to abort find, but got", err)
+	}
+	if err := NewSession().First(&Secret{}); err == nil || err.Error() != "secret can not be queried" {
+		t.Fatal("expect BeforeQuery to abort first, but got", err)
+	}
+}
+
+type Profile struct {
+	Name  string `geeorm:"PRIMARY KEY"`
+	Email string
+	calls []string
+}
+
+func (p *Profile) AfterInsert(s *Session) error {
+	p.calls = append(p.calls, AfterInsert+" "+p.Name)
+	return nil
+}
+
+func (p *Profile) BeforeUpdate(s *Session) error {
+	p.calls = append(p.calls, BeforeUpdate+" "+p.Email)
+	return nil
+}
+
+func (p *Profile) AfterDelete(s *Session) error {
+	p.calls = append(p.calls, AfterDelete+" "+p.Name)
+	return nil
+}
+
+func TestSession_ModelHooks(t *testing.T) {
+	s := NewSession().Model(&Profile{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	tom, sam := &Profile{Name: "Tom"}, &Profile{Name: "Sam"}
+	if _, err := s.Insert(tom, sam); err != nil || len(tom.calls) != 1 || len(sam.calls) != 1 {
+		t.Fatal("failed to call AfterInsert on each value", tom.calls, sam.calls)
+	}
+
+	tom.Email = "tom@example.com"
+	if affected, err := s.UpdateModel(tom); err != nil || affected != 1 || tom.calls[1] != "BeforeUpdate tom@example.com" {
+		t.Fatal("failed to update model", err, tom.calls)
+	}
+	u := &Profile{}
+	if _ = s.Where("Name = ?", "Tom").First(u); u.Email != "tom@example.com" {
+		t.Fatal("failed to update model, got", u)
+	}
+
+	if affected, err := s.DeleteModel(sam); err != nil || affected != 1 || sam.calls[1] != "AfterDelete Sam" {
+		t.Fatal("failed to delete model", err, sam.calls)
+	}
+	if count, _ := s.Count(); count != 1 {
+		t.Fatal("expect 1 record left, but got", count)
+	}
+}
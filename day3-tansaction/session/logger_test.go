@@ -0,0 +1,201 @@
+package session
+
+import (
+	"context"
+	"geeorm/log"
+	"geeorm/schema"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+// 记录所有日志的Logger，用于检查日志中的字段
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *captureLogger) add(level, msg string, fields []log.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: m})
+}
+
+func (l *captureLogger) Info(ctx context.Context, msg string, fields ...log.Field) {
+	l.add("info", msg, fields)
+}
+
+func (l *captureLogger) Warn(ctx context.Context, msg string, fields ...log.Field) {
+	l.add("warn", msg, fields)
+}
+
+func (l *captureLogger) Error(ctx context.Context, msg string, fields ...log.Field) {
+	l.add("error", msg, fields)
+}
+
+func (l *captureLogger) last() logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries[len(l.entries)-1]
+}
+
+func TestSession_Logger(t *testing.T) {
+	logger := &captureLogger{}
+	s := New(TestDB, TestDial, WithLogger(logger))
+	_, _ = s.Raw("DROP TABLE IF EXISTS User;").Exec()
+	_, _ = s.Raw("CREATE TABLE User(Name text);").Exec()
+	if _, err := s.Raw("INSERT INTO User(`Name`) values (?), (?)", "Tom", "Sam").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	e := logger.last()
+	if e.level != "info" || e.fields[log.FieldSQL] != "INSERT INTO User(`Name`) values (?), (?) " ||
+		e.fields[log.FieldRowsAffected] != int64(2) {
+		t.Fatalf("unexpected log entry %+v", e)
+	}
+	if vars, ok := e.fields[log.FieldVars].([]interface{}); !ok || len(vars) != 2 || vars[0] != "Tom" {
+		t.Fatalf("unexpected vars %v", e.fields[log.FieldVars])
+	}
+	if _, ok := e.fields[log.FieldDuration].(time.Duration); !ok {
+		t.Fatalf("expect duration, but got %v", e.fields[log.FieldDuration])
+	}
+
+	if _, err := s.Raw("SELECT * FROM NotExist").QueryRows(); err == nil {
+		t.Fatal("expect error")
+	}
+	if e = logger.last(); e.level != "error" || e.fields[log.FieldError] == nil {
+		t.Fatalf("unexpected log entry %+v", e)
+	}
+}
+
+func TestSession_SlowThreshold(t *testing.T) {
+	logger := &captureLogger{}
+	s := New(TestDB, TestDial, WithLogger(logger), WithSlowThreshold(time.Nanosecond))
+	if _, err := s.Raw("SELECT 1").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if e := logger.last(); e.level != "warn" || e.msg != "slow sql" {
+		t.Fatalf("expect slow sql warning, but got %+v", e)
+	}
+}
+
+func TestSession_WithLogger(t *testing.T) {
+	engineLogger, sessionLogger := &captureLogger{}, &captureLogger{}
+	s := New(TestDB, TestDial, WithLogger(engineLogger))
+	if _, err := s.WithLogger(sessionLogger).Raw("SELECT 1").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if len(engineLogger.entries) != 0 || len(sessionLogger.entries) != 1 {
+		t.Fatal("expect only the session logger to be used")
+	}
+}
+
+type Credential struct {
+	Name     string `geeorm:"PRIMARY KEY"`
+	Password string `geeorm:"sensitive"`
+	Avatar   []byte
+}
+
+func TestSession_Redact(t *testing.T) {
+	logger := &captureLogger{}
+	s := New(TestDB, TestDial, WithLogger(logger), WithLogValueLimit(4)).Model(&Credential{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	if _, err := s.Insert(&Credential{Name: "Tom", Password: "secret", Avatar: []byte("0123456789")}); err != nil {
+		t.Fatal(err)
+	}
+	vars := logger.last().fields[log.FieldVars].([]interface{})
+	if vars[0] != "Tom" || vars[1] != "***" || vars[2] != "30313233...(10 bytes)" {
+		t.Fatalf("unexpected vars %v", vars)
+	}
+
+	m := map[string]interface{}{"Password": "another"}
+	if _, err := s.Where("Name = ?", "Tom").Update(m); err != nil {
+		t.Fatal(err)
+	}
+	if vars = logger.last().fields[log.FieldVars].([]interface{}); vars[0] != "***" || vars[1] != "Tom" {
+		t.Fatalf("unexpected vars %v", vars)
+	}
+	if m["Password"] != "another" {
+		t.Fatal("expect the map passed to Update not to be modified")
+	}
+
+	var credentials []Credential
+	if err := s.Where("Password = ?", Sensitive("another")).Find(&credentials); err != nil || len(credentials) != 1 {
+		t.Fatal("failed to query with sensitive arg", err)
+	}
+	if vars = logger.last().fields[log.FieldVars].([]interface{}); vars[0] != "***" {
+		t.Fatalf("unexpected vars %v", vars)
+	}
+
+	//没有包装的参数根据前面的敏感列名遮蔽，在Find时才设置模型也可以识别
+	credentials = nil
+	err := New(TestDB, TestDial, WithLogger(logger)).Where(`Name = ? AND "Password" IN (?, ?)`, "Tom", "another", "x").Find(&credentials)
+	if err != nil || len(credentials) != 1 {
+		t.Fatal("failed to query with sensitive column", err)
+	}
+	if vars = logger.last().fields[log.FieldVars].([]interface{}); vars[0] != "Tom" || vars[1] != "***" || vars[2] != "***" {
+		t.Fatalf("unexpected vars %v", vars)
+	}
+
+	//执行出错时同样遮蔽
+	_, _ = s.Raw("INSERT INTO NotExist VALUES (?)", Sensitive("secret")).Exec()
+	if e := logger.last(); e.level != "error" || e.fields[log.FieldVars].([]interface{})[0] != "***" {
+		t.Fatalf("unexpected log entry %+v", e)
+	}
+}
+
+type Draft struct {
+	Title string
+}
+
+// 签名错误的钩子，不会被调用
+func (d *Draft) BeforeInsert() error {
+	return nil
+}
+
+func TestSession_InvalidHookWarning(t *testing.T) {
+	logger := &captureLogger{}
+	cache := schema.NewCache(logger)
+	//同一类型只在第一次解析时提示一次
+	for i := 0; i < 3; i++ {
+		New(TestDB, TestDial, WithSchemaCache(cache)).Model(&Draft{})
+	}
+	if len(logger.entries) != 1 {
+		t.Fatalf("expect one warning, but got %+v", logger.entries)
+	}
+	e := logger.last()
+	if e.level != "warn" || e.fields["model"] != "Draft" || e.fields["hook"] != BeforeInsert {
+		t.Fatalf("expect warning for invalid hook, but got %+v", e)
+	}
+}
+
+func TestMarkSensitive(t *testing.T) {
+	table := NewSession().Model(&Credential{}).RefTable()
+	cases := []struct {
+		sql  string
+		n    int
+		want []bool
+	}{
+		{"Name = ? AND Password = ?", 2, []bool{false, true}},
+		{"c.Password LIKE ? OR Name = ?", 2, []bool{true, false}},
+		{"Name = 'Password = ?' AND Password = ?", 1, []bool{true}},
+		{`"Password" NOT IN ($1, $2) AND Name = $3`, 3, []bool{true, true, false}},
+		{"Name = ? LIMIT ?", 2, nil},
+	}
+	for _, c := range cases {
+		if got := markSensitive(c.sql, table, c.n, nil); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("expect %v for %q, but got %v", c.want, c.sql, got)
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package session
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"geeorm/clause"
 	"geeorm/dialect"
 	"geeorm/log"
 	"geeorm/schema"
 	"strings"
+	"time"
 )
 
 // 用于实现数据库交互
@@ -20,17 +23,87 @@ type Session struct {
 
 	clause clause.Clause
 	//新增对事务的支持
-	tx *sql.Tx
+	tx *txState
+	//本次会话中所有SQL使用的上下文，用于取消和超时控制
+	ctx context.Context
+	//表名和列名的命名规则，为nil时使用schema.DefaultNaming
+	naming schema.NamingStrategy
+	//解析模型使用的缓存，为nil时每次都重新解析
+	schemas *schema.Cache
+	//Engine级别的回调注册表，以及当前SQL所属的操作类型
+	callbacks *Callbacks
+	op        Operation
+	//预编译语句缓存，为nil时不使用预编译语句
+	stmts *StmtCache
+	//日志输出，为nil时使用log.Default()；执行时间超过slowThreshold的SQL以warn级别记录，0表示不记录慢查询
+	logger        log.Logger
+	slowThreshold time.Duration
+	//日志中字符串和[]byte参数的最大长度，超过时被截断，0表示不截断
+	logValueLimit int
+	//不为nil时处于DryRun模式，SQL只被记录而不执行
+	dryRun *dryRunState
 }
 
-// 用于描述数据库操作的最小功能集合
+// Option 用于在创建Session时修改默认配置
+type Option func(*Session)
+
+// 指定Session解析模型时使用的命名规则
+func WithNamingStrategy(naming schema.NamingStrategy) Option {
+	return func(s *Session) {
+		s.naming = naming
+	}
+}
+
+// 指定Session解析模型时使用的Schema缓存
+func WithSchemaCache(schemas *schema.Cache) Option {
+	return func(s *Session) {
+		s.schemas = schemas
+	}
+}
+
+// 指定Session执行SQL前后调用的回调
+func WithCallbacks(callbacks *Callbacks) Option {
+	return func(s *Session) {
+		s.callbacks = callbacks
+	}
+}
+
+// 指定Session使用的预编译语句缓存
+func WithStmtCache(stmts *StmtCache) Option {
+	return func(s *Session) {
+		s.stmts = stmts
+	}
+}
+
+// 指定Session使用的Logger
+func WithLogger(logger log.Logger) Option {
+	return func(s *Session) {
+		s.logger = logger
+	}
+}
+
+// 指定慢查询的阈值，执行时间不小于d的SQL以warn级别记录
+func WithSlowThreshold(d time.Duration) Option {
+	return func(s *Session) {
+		s.slowThreshold = d
+	}
+}
+
+// 指定日志中字符串和[]byte参数的最大字节数，超过时被截断，用于避免大字段刷屏
+func WithLogValueLimit(n int) Option {
+	return func(s *Session) {
+		s.logValueLimit = n
+	}
+}
+
+// 用于描述数据库操作的最小功能集合，所有方法都携带context
 type CommonDB interface {
 	//用于执行查询语句，并返回查询结果的行集合和一个可能的错误
-	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	//用于执行查询语句，并返回查询结果的单行数据
-	QueryRow(query string, args ...interface{}) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 	//用于执行非查询语句，返回关于执行结果的一些信息，如受影响的行数等
-	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 // 实现对sql.DB 和 sql.Tx 的显式声明，用于确保它们都实现了CommonDB接口。
@@ -39,53 +112,294 @@ var _ CommonDB = (*sql.Tx)(nil)
 
 func (s *Session) DB() CommonDB {
 	//新增！=nil判断，用于事务
-	if s.tx != nil {
-		return s.tx
+	if s.inTx() {
+		return s.tx.tx
 	}
 	return s.db
 }
-func New(db *sql.DB, dialect dialect.Dialect) *Session {
-	return &Session{
+func New(db *sql.DB, dialect dialect.Dialect, opts ...Option) *Session {
+	s := &Session{
 		db:      db,
 		dialect: dialect,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.clause = s.newClause()
+	return s
+}
+
+// 创建一个使用当前dialect占位符的Clause
+func (s *Session) newClause() clause.Clause {
+	if s.dialect == nil {
+		return clause.Clause{}
+	}
+	return clause.Clause{Placeholder: s.dialect.Placeholder}
+}
+
+// 将原生SQL中的?替换为当前dialect的占位符
+func (s *Session) rebind(sql string) string {
+	if s.dialect == nil {
+		return sql
+	}
+	return clause.Rebind(sql, s.dialect.Placeholder)
+}
+
+// 复制一份会话：SQL、参数和子句等语句状态被深拷贝，数据库连接、事务和模型等状态共享。
+// 链式方法都在副本上修改，因此同一个Session可以被多个goroutine同时使用，
+// 未执行完的链式调用也不会影响之后的查询
+func (s *Session) clone() *Session {
+	c := *s
+	c.sql = strings.Builder{}
+	c.sql.WriteString(s.sql.String())
+	c.sqlVars = append([]interface{}(nil), s.sqlVars...)
+	c.clause = s.clause.Clone()
+	return &c
+}
+
+// 返回使用ctx的会话副本，之后执行的SQL和事务都会受该上下文控制
+func (s *Session) WithContext(ctx context.Context) *Session {
+	c := s.clone()
+	c.ctx = ctx
+	return c
+}
+
+// 返回使用logger的会话副本，用于单独调整某个会话的日志输出
+func (s *Session) WithLogger(logger log.Logger) *Session {
+	c := s.clone()
+	c.logger = logger
+	return c
+}
+
+// 返回会话使用的Logger
+func (s *Session) Logger() log.Logger {
+	if s.logger == nil {
+		return log.Default()
+	}
+	return s.logger
+}
+
+// 返回会话的上下文，未设置时返回context.Background()
+func (s *Session) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
 }
 
 func (s *Session) Clear() {
 	s.sql.Reset()
 	s.sqlVars = nil
-	s.clause = clause.Clause{}
+	s.clause = s.newClause()
+	s.op = OpRaw
 }
 
+// ErrEmptySQL 在没有SQL时由Exec和QueryRows返回。Raw返回新的会话而不修改原来的会话，
+// 需要在其返回值上继续调用Raw或执行，忽略返回值时SQL不会被执行
+var ErrEmptySQL = errors.New("sql is empty")
+
+// 返回追加了sql和参数的会话副本
 func (s *Session) Raw(sql string, values ...interface{}) *Session {
-	s.sql.WriteString(sql)
-	s.sql.WriteString(" ")
-	s.sqlVars = append(s.sqlVars, values...)
-	return s
+	c := s.clone()
+	c.sql.WriteString(sql)
+	c.sql.WriteString(" ")
+	c.sqlVars = append(c.sqlVars, values...)
+	return c
+}
+
+// 根据当前的SQL构造传递给回调的Scope
+func (s *Session) newScope() *Scope {
+	vars, sensitive := unwrapVars(s.sqlVars)
+	sql := s.rebind(s.sql.String())
+	sensitive = markSensitive(sql, s.refTable, len(vars), sensitive)
+	scope := &Scope{
+		Session:   s,
+		Operation: s.op,
+		SQL:       sql,
+		Vars:      vars,
+		sensitive: sensitive,
+	}
+	if s.op != OpRaw {
+		scope.Table = s.refTable
+	}
+	return scope
+}
+
+// 记录一条SQL的执行情况，rowsAffected小于0表示未知。出错时使用error级别，慢查询使用warn级别
+func (s *Session) logSQL(scope *Scope, start time.Time, rowsAffected int64, err error) {
+	elapsed := time.Since(start)
+	fields := []log.Field{
+		log.Any(log.FieldSQL, scope.SQL),
+		log.Any(log.FieldVars, s.logVars(scope)),
+		log.Any(log.FieldDuration, elapsed),
+	}
+	if rowsAffected >= 0 {
+		fields = append(fields, log.Any(log.FieldRowsAffected, rowsAffected))
+	}
+	switch {
+	case err != nil:
+		s.Logger().Error(s.Context(), "sql failed", append(fields, log.Any(log.FieldError, err))...)
+	case s.slowThreshold > 0 && elapsed >= s.slowThreshold:
+		s.Logger().Warn(s.Context(), "slow sql", fields...)
+	default:
+		s.Logger().Info(s.Context(), "sql", fields...)
+	}
 }
 
 // 封装三个函数，使用log统一打印日志，而且每次操作执行之后清空sql的两个变量，这样Session可以复用
 // 开启一次会话可以执行多次SQL
 func (s *Session) Exec() (result sql.Result, err error) {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if result, err = s.DB().Exec(s.sql.String(), s.sqlVars...); err != nil {
-		log.Error(err)
+	if err = s.checkSQL(); err != nil {
+		return
+	}
+	scope := s.newScope()
+	if err = s.callbacks.run(scope, false); err != nil {
+		s.Logger().Error(s.Context(), "callback failed", log.Any(log.FieldSQL, scope.SQL), log.Any(log.FieldError, err))
+		return
+	}
+	if s.dryRun != nil {
+		s.dryRun.record(scope)
+		return dryRunResult{}, nil
+	}
+	start := time.Now()
+	scope.RowsAffected = -1
+	if result, err = s.execContext(scope.SQL, scope.Vars...); err == nil {
+		scope.RowsAffected, _ = result.RowsAffected()
+	}
+	s.logSQL(scope, start, scope.RowsAffected, err)
+	scope.Error = err
+	if cbErr := s.callbacks.run(scope, true); cbErr != nil && err == nil {
+		err = cbErr
 	}
 	return
 }
 
+// *sql.Row无法携带自定义的错误，before回调出错或没有SQL时与Exec、QueryRows一样不执行查询，也不调用after回调，
+// 返回的Row在Scan时返回context.Canceled，错误只会被记录；after回调的错误同样只会被记录。
+// 需要取得回调错误时使用QueryRows
 func (s *Session) QueryRow() *sql.Row {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	return s.DB().QueryRow(s.sql.String(), s.sqlVars...)
+	scope := s.newScope()
+	ctx := s.Context()
+	if s.checkSQL() != nil {
+		return s.canceledRow(scope)
+	}
+	if err := s.callbacks.run(scope, false); err != nil {
+		s.Logger().Error(ctx, "callback failed", log.Any(log.FieldSQL, scope.SQL), log.Any(log.FieldError, err))
+		return s.canceledRow(scope)
+	}
+	if s.dryRun != nil {
+		//DryRun模式同样返回Scan时得到context.Canceled的Row
+		s.dryRun.record(scope)
+		return s.canceledRow(scope)
+	}
+	start := time.Now()
+	row := s.queryRowContext(ctx, scope.SQL, scope.Vars...)
+	s.logSQL(scope, start, -1, row.Err())
+	scope.Error = row.Err()
+	if err := s.callbacks.run(scope, true); err != nil {
+		s.Logger().Error(ctx, "callback failed", log.Any(log.FieldSQL, scope.SQL), log.Any(log.FieldError, err))
+	}
+	return row
+}
+
+// 没有SQL时记录错误并返回ErrEmptySQL，通常是忽略了Raw的返回值
+func (s *Session) checkSQL() error {
+	if strings.TrimSpace(s.sql.String()) == "" {
+		s.Logger().Error(s.Context(), "sql is empty", log.Any(log.FieldError, ErrEmptySQL))
+		return ErrEmptySQL
+	}
+	return nil
+}
+
+// 使用已经取消的context构造*sql.Row，database/sql在取得连接前就会返回，不会访问数据库
+func (s *Session) canceledRow(scope *Scope) *sql.Row {
+	ctx, cancel := context.WithCancel(s.Context())
+	cancel()
+	return s.DB().QueryRowContext(ctx, scope.SQL, scope.Vars...)
 }
 
 func (s *Session) QueryRows() (rows *sql.Rows, err error) {
 	defer s.Clear()
-	log.Info(s.sql.String(), s.sqlVars)
-	if rows, err = s.DB().Query(s.sql.String(), s.sqlVars...); err != nil {
-		log.Error(err)
+	if err = s.checkSQL(); err != nil {
+		return
+	}
+	scope := s.newScope()
+	if err = s.callbacks.run(scope, false); err != nil {
+		s.Logger().Error(s.Context(), "callback failed", log.Any(log.FieldSQL, scope.SQL), log.Any(log.FieldError, err))
+		return
+	}
+	if s.dryRun != nil {
+		s.dryRun.record(scope)
+		return nil, ErrDryRun
+	}
+	start := time.Now()
+	rows, err = s.queryContext(scope.SQL, scope.Vars...)
+	s.logSQL(scope, start, -1, err)
+	scope.Error = err
+	if cbErr := s.callbacks.run(scope, true); cbErr != nil && err == nil {
+		_ = rows.Close()
+		rows, err = nil, cbErr
 	}
 	return
 }
+
+// 从缓存中取出query对应的预编译语句，用完后需调用release；返回nil时直接在s.DB()上执行。
+// 事务已经占用了一个连接，在db上预编译需要连接池中的另一个连接，连接数为1时会死锁，
+// 因此事务中只在命中时使用tx.Stmt将缓存的语句绑定到事务的连接上，未命中时直接在事务上执行
+func (s *Session) stmt(ctx context.Context, query string) (stmt *sql.Stmt, release func(), err error) {
+	if s.stmts == nil || !cacheable(query) {
+		return
+	}
+	if !s.inTx() {
+		return s.stmts.get(ctx, query)
+	}
+	cached, cacheRelease, ok := s.stmts.lookup(query)
+	if !ok {
+		return
+	}
+	txStmt := s.tx.tx.StmtContext(ctx, cached)
+	return txStmt, func() {
+		_ = txStmt.Close()
+		cacheRelease()
+	}, nil
+}
+
+func (s *Session) execContext(query string, args ...interface{}) (sql.Result, error) {
+	stmt, release, err := s.stmt(s.Context(), query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return s.DB().ExecContext(s.Context(), query, args...)
+	}
+	defer release()
+	return stmt.ExecContext(s.Context(), args...)
+}
+
+// 语句在返回的Rows关闭前不会被真正关闭，因此release可以在查询返回后立即调用
+func (s *Session) queryContext(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, release, err := s.stmt(s.Context(), query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return s.DB().QueryContext(s.Context(), query, args...)
+	}
+	defer release()
+	return stmt.QueryContext(s.Context(), args...)
+}
+
+func (s *Session) queryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, release, err := s.stmt(ctx, query)
+	if err != nil {
+		//预编译失败时退回到直接执行，由Row.Scan返回错误
+		s.Logger().Error(ctx, "prepare failed", log.Any(log.FieldSQL, query), log.Any(log.FieldError, err))
+	}
+	if stmt == nil {
+		return s.DB().QueryRowContext(ctx, query, args...)
+	}
+	defer release()
+	return stmt.QueryRowContext(ctx, args...)
+}
@@ -1,7 +1,9 @@
 package session
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"geeorm/dialect"
 	_ "github.com/mattn/go-sqlite3"
 	"os"
@@ -44,3 +46,31 @@ func TestSession_QueryRows(t *testing.T) {
 		t.Fatal("failed to query db", err)
 	}
 }
+
+func TestSession_EmptySQL(t *testing.T) {
+	s := NewSession()
+	//Raw返回新的会话，忽略返回值时s上没有SQL
+	s.Raw("SELECT 1")
+	if _, err := s.Exec(); !errors.Is(err, ErrEmptySQL) {
+		t.Fatal("expect ErrEmptySQL, but got", err)
+	}
+	if _, err := s.QueryRows(); !errors.Is(err, ErrEmptySQL) {
+		t.Fatal("expect ErrEmptySQL, but got", err)
+	}
+	var n int
+	if err := s.QueryRow().Scan(&n); err == nil {
+		t.Fatal("expect error for empty sql")
+	}
+}
+
+func TestSession_WithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSession().WithContext(ctx)
+	if _, err := s.Raw("SELECT 1").Exec(); !errors.Is(err, context.Canceled) {
+		t.Fatal("expect context canceled, but got", err)
+	}
+	if _, err := s.Raw("SELECT 1").QueryRows(); !errors.Is(err, context.Canceled) {
+		t.Fatal("expect context canceled, but got", err)
+	}
+}
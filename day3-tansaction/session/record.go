@@ -2,43 +2,188 @@ package session
 
 import (
 	"errors"
+	"fmt"
 	"geeorm/clause"
+	"geeorm/schema"
 	"reflect"
 )
 
-// 将已经存在的对象的每一个字段的值平铺开来
+// 将已经存在的对象的每一个字段的值平铺开来。
+// 自增主键为零值时不写入，由数据库生成后写回传入的结构体指针中
 func (s *Session) Insert(values ...interface{}) (int64, error) {
-	recordValues := make([]interface{}, 0)
+	if len(values) == 0 {
+		return 0, errors.New("no values to insert")
+	}
+	//一条INSERT语句只能写入同一张表
+	typ := reflect.Indirect(reflect.ValueOf(values[0])).Type()
+	for _, value := range values[1:] {
+		if t := reflect.Indirect(reflect.ValueOf(value)).Type(); t != typ {
+			return 0, fmt.Errorf("can not insert %s and %s in one statement", typ, t)
+		}
+	}
+	s = s.clone()
+	var table *schema.Schema
 	for _, value := range values {
-		s.CallMethod(BeforeInsert, value)
-		table := s.Model(value).RefTable()
-		s.clause.Set(clause.INSERT, table.Name, table.FieldNames) //多次调用clause.Set构造好每个子句
-		recordValues = append(recordValues, table.RecordValues(value))
+		if err := s.CallMethod(BeforeInsert, value); err != nil {
+			s.Clear()
+			return 0, err
+		}
+		s = s.Model(value)
+		table = s.RefTable()
 	}
-
-	s.clause.Set(clause.VALUES, recordValues...)              //构造子句
-	sql, vars := s.clause.Build(clause.INSERT, clause.VALUES) //调用一次clause.Build按照传入的顺序构造出最终的SQL语句
-	result, err := s.Raw(sql, vars...).Exec()                 //调用Raw.Exec方法执行
+	auto, err := autoIncrementField(table, values)
 	if err != nil {
+		s.Clear()
+		return 0, err
+	}
+	var fields []*schema.Field
+	var columns []string
+	for _, field := range table.Fields {
+		if field != auto {
+			fields = append(fields, field)
+			columns = append(columns, field.ColumnName)
+		}
+	}
+	recordValues := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		dest := reflect.Indirect(reflect.ValueOf(value))
+		vals := make([]interface{}, 0, len(fields))
+		for _, field := range fields {
+			vals = append(vals, bindValue(field, field.DBValue(field.ValueOf(dest))))
+		}
+		recordValues = append(recordValues, vals)
+	}
+
+	s.clause.Set(clause.INSERT, s.quote(table.Name), s.quoteAll(columns)) //构造子句
+	s.clause.Set(clause.VALUES, recordValues...)                          //构造子句
+	sql, vars := s.clause.Build(clause.INSERT, clause.VALUES)             //调用一次clause.Build按照传入的顺序构造出最终的SQL语句
+	s.op = OpCreate
+	returning := ""
+	if auto != nil {
+		returning = s.dialect.InsertReturning(s.quote(auto.ColumnName))
+	}
+	var affected int64
+	if returning != "" {
+		affected, err = s.insertReturning(sql+" "+returning, vars, auto, values)
+	} else {
+		affected, err = s.insertExec(sql, vars, auto, values)
+	}
+	if err != nil || s.dryRun != nil {
 		return 0, err
 	}
-	s.CallMethod(AfterInsert, nil)
+	//在每一个插入的对象上调用AfterInsert
+	for _, value := range values {
+		if err = s.CallMethod(AfterInsert, value); err != nil {
+			return 0, err
+		}
+	}
+	return affected, nil
+}
+
+// 返回插入时需要由数据库生成的自增主键：所有对象的自增主键都是零值时返回该字段，都不是零值时返回nil。
+// 一条INSERT语句的列是相同的，因此两者混合时返回错误
+func autoIncrementField(table *schema.Schema, values []interface{}) (*schema.Field, error) {
+	field := table.PrimaryField
+	if field == nil || !field.AutoIncrement {
+		return nil, nil
+	}
+	zeros := 0
+	for _, value := range values {
+		if reflect.ValueOf(field.ValueOf(reflect.Indirect(reflect.ValueOf(value)))).IsZero() {
+			zeros++
+		}
+	}
+	switch zeros {
+	case 0:
+		return nil, nil
+	case len(values):
+		return field, nil
+	}
+	return nil, fmt.Errorf("can not insert records with and without %s in one statement", field.Name)
+}
+
+// 使用Exec插入，通过LastInsertId计算每一行的自增值
+func (s *Session) insertExec(sql string, vars []interface{}, auto *schema.Field, values []interface{}) (int64, error) {
+	result, err := s.Raw(sql, vars...).Exec() //调用Raw.Exec方法执行
+	if err != nil || s.dryRun != nil {
+		return 0, err
+	}
+	if auto != nil {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		first := s.dialect.FirstInsertID(id, len(values))
+		for i, value := range values {
+			setAutoIncrement(auto, value, first+int64(i))
+		}
+	}
 	return result.RowsAffected()
 }
 
+// 使用RETURNING子句插入，按照插入的顺序读取每一行的自增值
+func (s *Session) insertReturning(sql string, vars []interface{}, auto *schema.Field, values []interface{}) (int64, error) {
+	rows, err := s.Raw(sql, vars...).QueryRows()
+	if err == ErrDryRun {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var n int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		if n < int64(len(values)) {
+			setAutoIncrement(auto, values[n], id)
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// 把数据库生成的自增值写回value，value不是指针时无法写回
+func setAutoIncrement(field *schema.Field, value interface{}, id int64) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	dest := reflect.ValueOf(field.Addr(v.Elem())).Elem()
+	switch dest.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		dest.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		dest.SetUint(uint64(id))
+	}
+}
+
 // 期望调用方法是：传入一个切片指针，查询的结果保存到切片中
 // 根据平铺开的字段的值构造出对象。！反射！
 // 新增：钩子Hooks修改Find调用 函数CallMethod
 func (s *Session) Find(values interface{}) error {
-	s.CallMethod(BeforeQuery, nil)
-
+	s = s.clone()
 	destSlice := reflect.Indirect(reflect.ValueOf(values))
 	destType := destSlice.Type().Elem()
-	table := s.Model(reflect.New(destType).Elem().Interface()).RefTable() //获取表数据
+	//钩子按照惯例使用指针接收者，因此在指向新建对象的指针上调用BeforeQuery
+	model := reflect.New(destType).Interface()
+	s = s.Model(model)
+	table := s.RefTable() //获取表数据
+
+	if err := s.CallMethod(BeforeQuery, model); err != nil {
+		s.Clear()
+		return err
+	}
 
-	s.clause.Set(clause.SELECT, table.Name, table.FieldNames)                              // 拼接SQL语句
+	s.clause.Set(clause.SELECT, s.quote(table.Name), s.quoteAll(table.FieldNames))         // 拼接SQL语句
 	sql, vars := s.clause.Build(clause.SELECT, clause.WHERE, clause.ORDERBY, clause.LIMIT) //构造最终语句
-	rows, err := s.Raw(sql, vars...).QueryRows()                                           //根据传入的sql,vars在raw构造一个Session对象，来获取数据库表的数据
+	s.op = OpQuery
+	rows, err := s.Raw(sql, vars...).QueryRows() //根据传入的sql,vars在raw构造一个Session对象，来获取数据库表的数据
+	if err == ErrDryRun {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -46,16 +191,25 @@ func (s *Session) Find(values interface{}) error {
 	for rows.Next() {
 		dest := reflect.New(destType).Elem() //dest是指向结构体的指针，需要用reflect.New(destType).Elem()创建一个新的结构体实例
 		var values []interface{}
-		for _, name := range table.FieldNames {
-			// dest.FieldByName(name).Addr().Interface() ：获取目标结构体 dest 中指定字段名 name 的指针，并将其转换为 interface{} 类型的值，以便在 rows.Scan() 中将查询结果赋值给对应字段。
-			values = append(values, dest.FieldByName(name).Addr().Interface()) //获取结构体中所有字段的指针，然后把指针传递给Scan
+		for _, field := range table.Fields {
+			// field.ScanDest(dest) ：按照索引路径获取目标结构体 dest 中对应字段的指针（序列化的字段为反序列化用的Scanner），嵌入结构体中的字段也可以获取，以便在 rows.Scan() 中将查询结果赋值给对应字段。
+			values = append(values, field.ScanDest(dest)) //获取结构体中所有字段的指针，然后把指针传递给Scan
 		}
 		if err := rows.Scan(values...); err != nil {
+			_ = rows.Close()
 			return err
 		} //通过rows.Scan方法将查询结果映射到一个结构体实例dest中
-		s.CallMethod(AfterQuery, dest.Addr().Interface())
+		if err := s.CallMethod(AfterQuery, dest.Addr().Interface()); err != nil {
+			_ = rows.Close()
+			return err
+		}
 		destSlice.Set(reflect.Append(destSlice, dest)) //利用反射机制将dest添加到destSlice中
 	}
+	//遍历过程中上下文被取消或者连接出错时rows.Next返回false，需要检查rows.Err，否则会返回不完整的结果
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
 	return rows.Close()
 	//通过这种方式，可以将查询结果转换为目标类型的值，而无需手动编写扫描代码或添加每个字段的 setter 方法，极大地减少了代码复杂度。这也体现了反射机制在 ORM 框架中的重要性和实际应用场景。
 }
@@ -64,8 +218,15 @@ func (s *Session) Find(values interface{}) error {
 // 目的是为了兼容不同的调用方式，既可以接收一个显式传递的map，也可以接受一组键值对作为参数，
 // 并将它们转换为同一个的map格式
 func (s *Session) Update(kv ...interface{}) (int64, error) {
+	if s.RefTable() == nil {
+		return 0, ErrModelNotSet
+	}
+	s = s.clone()
 	//新增回调函数
-	s.CallMethod(BeforeUpdate, nil)
+	if err := s.CallMethod(BeforeUpdate, nil); err != nil {
+		s.Clear()
+		return 0, err
+	}
 	//首先通过强制转换，如果转换成功则直接赋值给变量m
 	//如果转换失败，则表示第一个参数不是map类型，需要通过遍历参数切片kv构建一个新的map
 	m, ok := kv[0].(map[string]interface{})
@@ -76,55 +237,165 @@ func (s *Session) Update(kv ...interface{}) (int64, error) {
 			m[kv[i].(string)] = kv[i+1]
 		}
 	}
-	s.clause.Set(clause.UPDATE, s.RefTable().Name, m)
+	//复制一份再将结构体字段名转换为列名、标记敏感字段，不修改调用方传入的map
+	table := s.RefTable()
+	values := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if field := table.GetField(k); field != nil {
+			values[s.quote(field.ColumnName)] = bindValue(field, field.DBValue(v))
+		} else {
+			values[k] = v
+		}
+	}
+	s.clause.Set(clause.UPDATE, s.quote(table.Name), values)
 	sql, vars := s.clause.Build(clause.UPDATE, clause.WHERE)
+	s.op = OpUpdate
 	result, err := s.Raw(sql, vars...).Exec()
-	if err != nil {
+	if err != nil || s.dryRun != nil {
+		return 0, err
+	}
+	if err = s.CallMethod(AfterUpdate, nil); err != nil {
 		return 0, err
 	}
-	s.CallMethod(AfterUpdate, nil)
 	return result.RowsAffected()
 
 }
 
 func (s *Session) Delete() (int64, error) {
-	s.CallMethod(BeforeDelete, nil)
-	s.clause.Set(clause.DELETE, s.RefTable().Name)
+	if s.RefTable() == nil {
+		return 0, ErrModelNotSet
+	}
+	s = s.clone()
+	if err := s.CallMethod(BeforeDelete, nil); err != nil {
+		s.Clear()
+		return 0, err
+	}
+	s.clause.Set(clause.DELETE, s.quote(s.RefTable().Name))
 	sql, vars := s.clause.Build(clause.DELETE, clause.WHERE)
+	s.op = OpDelete
 	result, err := s.Raw(sql, vars...).Exec()
-	if err != nil {
+	if err != nil || s.dryRun != nil {
+		return 0, err
+	}
+	if err = s.CallMethod(AfterDelete, nil); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// 根据主键更新value对应的记录，除主键外的所有字段都会被更新，钩子在value上调用
+func (s *Session) UpdateModel(value interface{}) (int64, error) {
+	s = s.clone()
+	s = s.Model(value)
+	table := s.RefTable()
+	if table.PrimaryField == nil {
+		s.Clear()
+		return 0, fmt.Errorf("table %s has no primary key", table.Name)
+	}
+	if err := s.CallMethod(BeforeUpdate, value); err != nil {
+		s.Clear()
+		return 0, err
+	}
+	m := make(map[string]interface{})
+	var pk interface{}
+	for i, v := range table.RecordValues(value) {
+		if field := table.Fields[i]; field == table.PrimaryField {
+			pk = bindValue(field, v)
+		} else {
+			m[s.quote(field.ColumnName)] = bindValue(field, v)
+		}
+	}
+	s.clause.Set(clause.UPDATE, s.quote(table.Name), m)
+	s.clause.Set(clause.WHERE, s.quote(table.PrimaryField.ColumnName)+" = ?", pk)
+	sql, vars := s.clause.Build(clause.UPDATE, clause.WHERE)
+	s.op = OpUpdate
+	result, err := s.Raw(sql, vars...).Exec()
+	if err != nil || s.dryRun != nil {
+		return 0, err
+	}
+	if err = s.CallMethod(AfterUpdate, value); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// 根据主键删除value对应的记录，钩子在value上调用
+func (s *Session) DeleteModel(value interface{}) (int64, error) {
+	s = s.clone()
+	s = s.Model(value)
+	table := s.RefTable()
+	if table.PrimaryField == nil {
+		s.Clear()
+		return 0, fmt.Errorf("table %s has no primary key", table.Name)
+	}
+	if err := s.CallMethod(BeforeDelete, value); err != nil {
+		s.Clear()
+		return 0, err
+	}
+	pk := table.PrimaryField.ValueOf(reflect.Indirect(reflect.ValueOf(value)))
+	pk = bindValue(table.PrimaryField, pk)
+	s.clause.Set(clause.DELETE, s.quote(table.Name))
+	s.clause.Set(clause.WHERE, s.quote(table.PrimaryField.ColumnName)+" = ?", pk)
+	sql, vars := s.clause.Build(clause.DELETE, clause.WHERE)
+	s.op = OpDelete
+	result, err := s.Raw(sql, vars...).Exec()
+	if err != nil || s.dryRun != nil {
+		return 0, err
+	}
+	if err = s.CallMethod(AfterDelete, value); err != nil {
 		return 0, err
 	}
-	s.CallMethod(AfterDelete, nil)
 	return result.RowsAffected()
 }
 
 func (s *Session) Count() (int64, error) {
-	s.clause.Set(clause.COUNT, s.RefTable().Name)
+	if s.RefTable() == nil {
+		return 0, ErrModelNotSet
+	}
+	s = s.clone()
+	s.clause.Set(clause.COUNT, s.quote(s.RefTable().Name))
 	sql, vars := s.clause.Build(clause.COUNT, clause.WHERE)
-	row := s.Raw(sql, vars...).QueryRow()
+	//使用QueryRows而不是QueryRow，以便返回回调的错误
+	s.op = OpQuery
+	rows, err := s.Raw(sql, vars...).QueryRows()
+	if err == ErrDryRun {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
 	var tmp int64
 	//scan方法：将查询结果存储到指定变量的方法；处理多行查询结果；处理不同数据类型的查询结果；错误处理
-	if err := row.Scan(&tmp); err != nil {
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	if err := rows.Scan(&tmp); err != nil {
 		return 0, err
 	}
 	return tmp, nil
 }
 
+// 链式方法返回设置了对应子句的会话副本，不修改原来的会话
 func (s *Session) Limit(num int) *Session {
-	s.clause.Set(clause.LIMIT, num)
-	return s
+	c := s.clone()
+	c.clause.Set(clause.LIMIT, num)
+	return c
 }
 
+// desc原样写入SQL，生成的表名和列名带有引号，而desc中的列名不会被加上引号，
+// 在PostgreSQL等会折叠大小写的数据库中需要自行使用与建表时一致的带引号的列名
 func (s *Session) Where(desc string, args ...interface{}) *Session {
 	var vars []interface{}
-	s.clause.Set(clause.WHERE, append(append(vars, desc), args...)...)
-	return s
+	c := s.clone()
+	c.clause.Set(clause.WHERE, append(append(vars, desc), args...)...)
+	return c
 }
 
 func (s *Session) OrderBy(desc string) *Session {
-	s.clause.Set(clause.ORDERBY, desc)
-	return s
+	c := s.clone()
+	c.clause.Set(clause.ORDERBY, desc)
+	return c
 }
 
 // 返回是是单个数据，可以直接将该记录的数据赋值给传入对象而不需要使用回调函数来处理查询结果
@@ -136,6 +407,9 @@ func (s *Session) First(value interface{}) error {
 		return err
 	}
 	if destSlice.Len() == 0 {
+		if s.dryRun != nil {
+			return nil
+		}
 		return errors.New("Not Found")
 	}
 	dest.Set(destSlice.Index(0))
@@ -1,6 +1,18 @@
 package session
 
-import "testing"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"geeorm/clause"
+	"geeorm/dialect"
+	"geeorm/schema"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 var (
 	user1 = &User{"Tom", 18}
@@ -28,6 +40,16 @@ func TestSession_Insert(t *testing.T) {
 	}
 }
 
+func TestSession_InsertInvalid(t *testing.T) {
+	s := NewSession()
+	if _, err := s.Insert(); err == nil {
+		t.Fatal("expect error when inserting nothing")
+	}
+	if _, err := s.Insert(&User{"Tom", 18}, &Account{ID: 1}); err == nil {
+		t.Fatal("expect error when inserting values of different types")
+	}
+}
+
 func TestSession_Find(t *testing.T) {
 	s := testRecordInit(t)
 	var users []User
@@ -65,3 +87,247 @@ func TestSession_DeleteAndCount(t *testing.T) {
 		t.Fatal("failed to delete or count")
 	}
 }
+
+func TestSession_Placeholder(t *testing.T) {
+	pg, _ := dialect.GetDialect("postgres")
+	s := New(nil, pg).Where("Name = ?", "Tom").Limit(1)
+	if sql, _ := s.clause.Build(clause.WHERE, clause.LIMIT); sql != "WHERE Name = $1 LIMIT $2" {
+		t.Fatal("failed to rebind placeholders, got", sql)
+	}
+	if sql := s.rebind("SELECT * FROM User WHERE Age > ?"); sql != "SELECT * FROM User WHERE Age > $1" {
+		t.Fatal("failed to rebind raw sql, got", sql)
+	}
+}
+
+type Order struct {
+	ID     int64  `geeorm:"primary key"`
+	Item   string `geeorm:"column:item_name;size:64"`
+	Amount int
+	Remark string `geeorm:"-"`
+}
+
+// 交替插入不同类型的模型，每次切换类型都会重新设置refTable。DryRun避免数据库的开销掩盖解析的开销
+func BenchmarkSession_InsertMixedModels(b *testing.B) {
+	run := func(b *testing.B, opts ...Option) {
+		s := New(TestDB, TestDial, opts...)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dry := s.DryRun()
+			_, _ = dry.Insert(&User{"Tom", 18})
+			_, _ = dry.Insert(&Order{ID: 1, Item: "book", Amount: 2})
+			_, _ = dry.Insert(&Product{ID: 1, Name: "pen"})
+		}
+	}
+	b.Run("NoCache", func(b *testing.B) {
+		run(b)
+	})
+	b.Run("Cache", func(b *testing.B) {
+		run(b, WithSchemaCache(schema.NewCache(nil)))
+	})
+}
+
+type Timestamps struct {
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+type Location struct {
+	City string
+}
+
+type Store struct {
+	ID int64 `geeorm:"primary key"`
+	Timestamps
+	*Location
+	Name string
+}
+
+func TestSession_Embedded(t *testing.T) {
+	s := NewSession().Model(&Store{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Insert(&Store{ID: 1, Timestamps: Timestamps{CreatedAt: 100}, Location: &Location{"Beijing"}, Name: "gee"},
+		&Store{ID: 2, Name: "orm"}); err != nil {
+		t.Fatal(err)
+	}
+	var stores []Store
+	if err := s.OrderBy("ID").Find(&stores); err != nil || len(stores) != 2 {
+		t.Fatal("failed to query embedded fields", err)
+	}
+	if stores[0].CreatedAt != 100 || stores[0].Location == nil || stores[0].City != "Beijing" || stores[1].Name != "orm" {
+		t.Fatalf("unexpected result %+v", stores)
+	}
+	if _, err := s.DeleteModel(&stores[0]); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := s.Count(); count != 1 {
+		t.Fatal("failed to delete by embedded primary key")
+	}
+}
+
+// 以分为单位存储的金额，实现了driver.Valuer和sql.Scanner
+type Money struct {
+	Cents int64
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	cents, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("can not scan %T into Money", src)
+	}
+	m.Cents = cents
+	return nil
+}
+
+type Invoice struct {
+	ID       int64 `geeorm:"primary key"`
+	Title    *string
+	Paid     sql.NullTime
+	Discount sql.NullFloat64
+	Total    Money
+	Note     string `geeorm:"type:varchar(32)"`
+}
+
+func TestSession_Nullable(t *testing.T) {
+	s := NewSession().Model(&Invoice{})
+	if sql := s.createTableSQL(); !strings.Contains(sql, `"Total" bigint`) || !strings.Contains(sql, `"Note" varchar(32)`) {
+		t.Fatal("unexpected create table sql", sql)
+	}
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	title := "gee"
+	if _, err := s.Insert(&Invoice{ID: 1, Title: &title, Discount: sql.NullFloat64{Float64: 0.5, Valid: true}, Total: Money{1999}},
+		&Invoice{ID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	var invoices []Invoice
+	if err := s.OrderBy("ID").Find(&invoices); err != nil || len(invoices) != 2 {
+		t.Fatal("failed to query nullable columns", err)
+	}
+	first, second := invoices[0], invoices[1]
+	if first.Title == nil || *first.Title != "gee" || !first.Discount.Valid || first.Discount.Float64 != 0.5 ||
+		first.Total.Cents != 1999 || first.Paid.Valid {
+		t.Fatalf("unexpected result %+v", first)
+	}
+	if second.Title != nil || second.Discount.Valid || second.Total.Cents != 0 {
+		t.Fatalf("expect null columns, but got %+v", second)
+	}
+}
+
+type Article struct {
+	ID     int64             `geeorm:"primary key"`
+	Tags   []string          `geeorm:"serializer:json"`
+	Meta   map[string]int    `geeorm:"serializer:json"`
+	Author Location          `geeorm:"serializer:gob"`
+	Extra  map[string]string `geeorm:"serializer:json"`
+}
+
+func TestSession_Serializer(t *testing.T) {
+	s := NewSession().Model(&Article{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	in := &Article{ID: 1, Tags: []string{"go", "orm"}, Meta: map[string]int{"likes": 3}, Author: Location{"Beijing"}}
+	if _, err := s.Insert(in, &Article{ID: 2, Tags: []string{"db"}}); err != nil {
+		t.Fatal(err)
+	}
+	var out Article
+	if err := s.Where("Tags = ?", schema.JSON([]string{"go", "orm"})).First(&out); err != nil {
+		t.Fatal("failed to compare serialized value", err)
+	}
+	if !reflect.DeepEqual(out, *in) {
+		t.Fatalf("expect %+v, but got %+v", *in, out)
+	}
+	if _, err := s.Where("ID = ?", 2).Update("Meta", map[string]int{"likes": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Where("ID = ?", 2).First(&out); err != nil || out.Meta["likes"] != 1 || out.Extra != nil {
+		t.Fatal("failed to update serialized column", out, err)
+	}
+}
+
+type Ticket struct {
+	ID    int64 `geeorm:"primaryKey;autoIncrement"`
+	Title string
+}
+
+func TestSession_AutoIncrement(t *testing.T) {
+	s := NewSession().Model(&Ticket{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	first, second := &Ticket{Title: "a"}, &Ticket{Title: "b"}
+	if _, err := s.Insert(first, second); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == 0 || second.ID != first.ID+1 {
+		t.Fatalf("expect generated ids, but got %d and %d", first.ID, second.ID)
+	}
+	third := &Ticket{Title: "c"}
+	if _, err := s.Insert(third); err != nil || third.ID != second.ID+1 {
+		t.Fatal("failed to write back id", third.ID, err)
+	}
+	if _, err := s.Insert(&Ticket{ID: 100, Title: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	var out Ticket
+	if err := s.Where("ID = ?", 100).First(&out); err != nil || out.Title != "d" {
+		t.Fatal("failed to insert explicit id", err)
+	}
+	if _, err := s.Insert(&Ticket{Title: "e"}, &Ticket{ID: 200, Title: "f"}); err == nil {
+		t.Fatal("expect error when mixing generated and explicit ids")
+	}
+}
+
+func TestSession_AutoIncrementReturning(t *testing.T) {
+	pg, _ := dialect.GetDialect("postgres")
+	dry := New(TestDB, pg).Model(&Ticket{}).DryRun()
+	order := &Ticket{Title: "a"}
+	if _, err := dry.Insert(order); err != nil {
+		t.Fatal(err)
+	}
+	want := `INSERT INTO "Ticket" ("Title") VALUES ($1) RETURNING "ID"`
+	if stmts := dry.Statements(); len(stmts) != 1 || stmts[0].SQL != want {
+		t.Fatalf("expect %s, but got %v", want, stmts)
+	}
+}
+
+type Visitor struct {
+	Name string `geeorm:"PRIMARY KEY"`
+}
+
+// 读取第一行后取消Find使用的上下文
+var cancelVisitorQuery context.CancelFunc
+
+func (v *Visitor) AfterQuery(s *Session) error {
+	cancelVisitorQuery()
+	return nil
+}
+
+func TestSession_FindCanceled(t *testing.T) {
+	s := NewSession().Model(&Visitor{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Insert(&Visitor{"a"}, &Visitor{"b"}, &Visitor{"c"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelVisitorQuery = cancel
+	var visitors []Visitor
+	if err := s.WithContext(ctx).Find(&visitors); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect context.Canceled, but got %v with %d rows", err, len(visitors))
+	}
+}
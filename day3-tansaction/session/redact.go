@@ -0,0 +1,170 @@
+package session
+
+import (
+	"fmt"
+	"geeorm/schema"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 记录日志时替换敏感参数的文本
+const redacted = "***"
+
+// 包装绑定到敏感字段的参数，执行前被解开，只影响日志
+type sensitiveValue struct {
+	v interface{}
+}
+
+// Sensitive 标记一个敏感参数，该参数仍然正常绑定到SQL，但在日志中被遮蔽。
+// 设置了模型时，Where("Password = ?", pwd) 中绑定到敏感列的参数会被自动识别，
+// 没有模型的原生SQL或者无法识别的写法需要使用 s.Where("md5(Password) = ?", session.Sensitive(pwd))
+func Sensitive(v interface{}) interface{} {
+	return sensitiveValue{v: v}
+}
+
+// 字段带有sensitive标签时包装v
+func bindValue(field *schema.Field, v interface{}) interface{} {
+	if field != nil && field.Sensitive {
+		return Sensitive(v)
+	}
+	return v
+}
+
+// 解开被标记的参数，返回传递给数据库的参数以及每个参数是否敏感，没有敏感参数时mask为nil
+func unwrapVars(vars []interface{}) (args []interface{}, mask []bool) {
+	for i, v := range vars {
+		sv, ok := v.(sensitiveValue)
+		if !ok {
+			continue
+		}
+		if mask == nil {
+			args = append([]interface{}(nil), vars...)
+			mask = make([]bool, len(vars))
+		}
+		args[i], mask[i] = sv.v, true
+	}
+	if mask == nil {
+		return vars, nil
+	}
+	return
+}
+
+// 位于列名和占位符之间、不改变比较对象的关键字，例如 Password LIKE ?、Password NOT IN (?, ?)
+var comparisonKeywords = map[string]bool{"LIKE": true, "ILIKE": true, "IN": true, "NOT": true, "IS": true, "BETWEEN": true}
+
+// 根据SQL文本标记绑定到敏感列的参数：占位符（?或者$n）前面最近的列名是table中的敏感字段时，
+// 该位置的参数被标记，因此 Where("Password = ?", pwd) 不需要调用方使用Sensitive包装也会被遮蔽。
+// 只识别"列名 比较符 占位符"的形式，无法识别的写法（例如函数调用）仍需使用Sensitive
+func markSensitive(sql string, table *schema.Schema, n int, mask []bool) []bool {
+	if table == nil || n == 0 {
+		return mask
+	}
+	var names []string
+	for _, field := range table.Fields {
+		if field.Sensitive {
+			names = append(names, field.ColumnName, field.Name)
+		}
+	}
+	if len(names) == 0 {
+		return mask
+	}
+	isSensitive := func(ident string) bool {
+		for _, name := range names {
+			if strings.EqualFold(ident, name) {
+				return true
+			}
+		}
+		return false
+	}
+	mark := func(i int) {
+		if i < 0 || i >= n {
+			return
+		}
+		if mask == nil {
+			mask = make([]bool, n)
+		}
+		mask[i] = true
+	}
+
+	runes := []rune(sql)
+	var column string //最近出现的列名，表名前缀已去掉
+	index := 0        //?占位符的序号
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'':
+			//跳过字符串常量
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+			}
+		case r == '"' || r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				j++
+			}
+			if j == len(runes) {
+				return mask
+			}
+			column = string(runes[i+1 : j])
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			if word := string(runes[i:j]); !comparisonKeywords[strings.ToUpper(word)] {
+				column = word
+			}
+			i = j - 1
+		case r == '?':
+			if isSensitive(column) {
+				mark(index)
+			}
+			index++
+		case r == '$':
+			j := i + 1
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			if k, err := strconv.Atoi(string(runes[i+1 : j])); err == nil && isSensitive(column) {
+				mark(k - 1)
+			}
+			i = j - 1
+		}
+	}
+	return mask
+}
+
+// 返回写入日志的参数：敏感参数被遮蔽，超过logValueLimit字节的字符串和[]byte被截断
+func (s *Session) logVars(scope *Scope) []interface{} {
+	if scope.sensitive == nil && s.logValueLimit <= 0 {
+		return scope.Vars
+	}
+	vars := make([]interface{}, len(scope.Vars))
+	for i, v := range scope.Vars {
+		//before回调可能修改了Vars，只遮蔽原来位置上的参数
+		if i < len(scope.sensitive) && scope.sensitive[i] {
+			vars[i] = redacted
+			continue
+		}
+		vars[i] = truncate(v, s.logValueLimit)
+	}
+	return vars
+}
+
+func truncate(v interface{}, limit int) interface{} {
+	if limit <= 0 {
+		return v
+	}
+	switch x := v.(type) {
+	case string:
+		if len(x) > limit {
+			return fmt.Sprintf("%s...(%d bytes)", x[:limit], len(x))
+		}
+	case []byte:
+		if len(x) > limit {
+			return fmt.Sprintf("%x...(%d bytes)", x[:limit], len(x))
+		}
+	}
+	return v
+}
@@ -0,0 +1,190 @@
+package session
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"geeorm/log"
+	"strings"
+	"sync"
+)
+
+// StmtCache 是按SQL字符串缓存*sql.Stmt的LRU缓存，超出容量时淘汰最久未使用的语句并将其关闭
+type StmtCache struct {
+	mu       sync.Mutex
+	db       *sql.DB
+	capacity int
+	logger   log.Logger               //记录关闭语句时的错误
+	ll       *list.List               //最近使用的在前
+	items    map[string]*list.Element //SQL到链表节点的映射
+
+	hits, misses, evictions uint64
+}
+
+// StmtCacheStats 缓存的统计信息
+type StmtCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  //正在使用该语句的调用数
+	evicted bool //已经被淘汰，最后一个使用者释放时关闭
+}
+
+// NewStmtCache 创建一个最多缓存capacity条语句的缓存，capacity小于1时按1处理，logger为nil时使用log.Default()
+func NewStmtCache(db *sql.DB, capacity int, logger log.Logger) *StmtCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &StmtCache{
+		db:       db,
+		capacity: capacity,
+		logger:   logger,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// 只在缓存中查找query对应的预编译语句，命中时ok为true，用完后必须调用release
+func (c *StmtCache) lookup(query string) (stmt *sql.Stmt, release func(), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[query]
+	if !ok {
+		c.misses++
+		return nil, nil, false
+	}
+	c.hits++
+	c.ll.MoveToFront(e)
+	entry := e.Value.(*stmtEntry)
+	entry.refs++
+	return entry.stmt, func() { c.release(entry) }, true
+}
+
+// 获取query对应的预编译语句，未命中时在db上预编译并加入缓存，用完后必须调用release
+func (c *StmtCache) get(ctx context.Context, query string) (stmt *sql.Stmt, release func(), err error) {
+	if stmt, release, ok := c.lookup(query); ok {
+		return stmt, release, nil
+	}
+
+	//预编译可能较慢，不在锁内进行
+	if stmt, err = c.db.PrepareContext(ctx, query); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	if e, ok := c.items[query]; ok {
+		//其他goroutine已经缓存了同一条语句，使用已有的并关闭刚创建的
+		c.ll.MoveToFront(e)
+		entry := e.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		_ = stmt.Close()
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.ll.PushFront(entry)
+	var evicted []*sql.Stmt
+	for c.ll.Len() > c.capacity {
+		c.evictions++
+		if s := c.remove(c.ll.Back()); s != nil {
+			evicted = append(evicted, s)
+		}
+	}
+	c.mu.Unlock()
+	c.closeStmts(evicted)
+	return stmt, func() { c.release(entry) }, nil
+}
+
+// 从缓存中移除e，如果没有调用正在使用它则返回需要关闭的语句
+func (c *StmtCache) remove(e *list.Element) *sql.Stmt {
+	entry := c.ll.Remove(e).(*stmtEntry)
+	delete(c.items, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		return entry.stmt
+	}
+	return nil
+}
+
+func (c *StmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	entry.refs--
+	closeNow := entry.evicted && entry.refs == 0
+	c.mu.Unlock()
+	if closeNow {
+		c.closeStmts([]*sql.Stmt{entry.stmt})
+	}
+}
+
+func (c *StmtCache) closeStmts(stmts []*sql.Stmt) {
+	for _, stmt := range stmts {
+		if err := stmt.Close(); err != nil {
+			c.logger.Error(context.Background(), "close statement failed", log.Any(log.FieldError, err))
+		}
+	}
+}
+
+// 判断query是否可以使用预编译语句缓存。预编译语句只会执行多语句SQL中的第一条，
+// DDL语句则会改变表结构并使已有的预编译语句失效，因此只缓存单条的SELECT/INSERT/UPDATE/DELETE
+func cacheable(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE":
+	default:
+		return false
+	}
+	var quote rune
+	for i, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';':
+			//只允许出现在末尾的分号
+			if strings.TrimSpace(query[i+1:]) != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Stats 返回缓存命中、未命中和淘汰的次数
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return StmtCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.ll.Len(),
+	}
+}
+
+// Close 清空缓存并关闭所有语句
+func (c *StmtCache) Close() {
+	c.mu.Lock()
+	var evicted []*sql.Stmt
+	for c.ll.Len() > 0 {
+		if s := c.remove(c.ll.Back()); s != nil {
+			evicted = append(evicted, s)
+		}
+	}
+	c.mu.Unlock()
+	c.closeStmts(evicted)
+}
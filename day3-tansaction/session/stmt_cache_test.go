@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStmtCache(t *testing.T) {
+	cache := NewStmtCache(TestDB, 1, nil)
+	defer cache.Close()
+	testRecordInit(t)
+	s := New(TestDB, TestDial, WithStmtCache(cache)).Model(&User{})
+
+	u := &User{}
+	for i := 0; i < 3; i++ {
+		if err := s.Where("Name = ?", "Tom").First(u); err != nil || u.Age != 18 {
+			t.Fatal("failed to query with cached statement", err)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 {
+		t.Fatal("unexpected cache stats", stats)
+	}
+
+	if count, err := s.Count(); err != nil || count != 2 {
+		t.Fatal("failed to count with cached statement", err)
+	}
+	if stats := cache.Stats(); stats.Evictions != 1 || stats.Size != 1 {
+		t.Fatal("expect the least recently used statement to be evicted", stats)
+	}
+
+	_, err := s.Transaction(func(s *Session) (interface{}, error) {
+		return s.Insert(user3)
+	})
+	if count, _ := s.Count(); err != nil || count != 3 {
+		t.Fatal("failed to insert with cached statement in transaction", err)
+	}
+}
+
+func TestStmtCache_SingleConn(t *testing.T) {
+	db, err := sql.Open("sqlite3", "./gee.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	cache := NewStmtCache(db, 8, nil)
+	defer cache.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s := New(db, TestDial, WithStmtCache(cache)).Model(&User{}).WithContext(ctx)
+	_ = s.DropTable()
+	if err = s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	//先在事务外缓存一条查询语句，事务中分别命中和未命中缓存
+	if _, err = s.Count(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Transaction(func(s *Session) (interface{}, error) {
+		if _, err := s.Insert(user1); err != nil {
+			return nil, err
+		}
+		var users []User
+		if err := s.Find(&users); err != nil || len(users) != 1 {
+			return nil, fmt.Errorf("failed to find in transaction, got %v %v", users, err)
+		}
+		return s.Count()
+	})
+	if err != nil {
+		t.Fatal("failed to use statement cache in transaction with one connection", err)
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT * FROM User WHERE Name = ?":                        true,
+		"INSERT INTO User VALUES (?);":                             true,
+		"SELECT * FROM User WHERE Name = 'a;b'":                    true,
+		"CREATE TABLE tmp AS SELECT Name FROM User;":               false,
+		"DROP TABLE User":                                          false,
+		"DELETE FROM User; DROP TABLE User":                        false,
+		"CREATE TABLE t AS SELECT 1; DROP TABLE User; ALTER TABLE": false,
+	}
+	for query, want := range cases {
+		if got := cacheable(query); got != want {
+			t.Fatalf("expect %v for %q, but got %v", want, query, got)
+		}
+	}
+}
@@ -1,52 +1,88 @@
 package session
 
 import (
+	"errors"
 	"fmt"
-	"geeorm/log"
 	"geeorm/schema"
 	"reflect"
 	"strings"
 )
 
-// 用于给refTable赋值。将解析的结果保存在refTable中，即使model（）被多次调用，如果传入的结构体名称不发生变化则不会更新refTable的值
+// ErrModelNotSet 在没有调用Model时由依赖模型的方法返回。Model返回新的会话，需要在其返回值上继续调用
+var ErrModelNotSet = errors.New("model is not set")
+
+// 返回解析了value的会话副本，不修改原来的会话。如果传入的结构体类型不变则直接返回原会话，不会重复解析。
+// 设置了Schema缓存时，不同会话之间也不会重复解析同一类型
 func (s *Session) Model(value interface{}) *Session {
-	if s.refTable == nil || reflect.TypeOf(value) != reflect.TypeOf(s.refTable.Model) {
-		s.refTable = schema.Parse(value, s.dialect)
+	if s.refTable != nil && reflect.TypeOf(value) == reflect.TypeOf(s.refTable.Model) {
+		return s
 	}
-	return s
+	c := s.clone()
+	c.refTable = c.schemas.Parse(value, c.dialect, c.naming)
+	return c
 }
 
 // 返回refTable的值，如果refTable未赋值则打印错误日志
 func (s *Session) RefTable() *schema.Schema {
 	if s.refTable == nil {
-		log.Error("Model is not set")
+		s.Logger().Error(s.Context(), "Model is not set")
 	}
 	return s.refTable
 }
 
+// 按照dialect的方式给表名或列名加上引号。生成的SQL中的标识符都经过quote，
+// 避免与保留字冲突，并使PostgreSQL等会折叠大小写的数据库与建表时的名称保持一致
+func (s *Session) quote(name string) string {
+	return s.dialect.Quote(name)
+}
+
+func (s *Session) quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = s.quote(name)
+	}
+	return quoted
+}
+
 // 接下来实现数据库表的创建、删除和判断是否存在的功能。
 // 利用RefTable（）返回的数据库表和字段的信息，拼接出SQL语句，调用原生SQL语句执行
 func (s *Session) CreateTable() error {
+	if s.RefTable() == nil {
+		return ErrModelNotSet
+	}
+	_, err := s.Raw(s.createTableSQL()).Exec()
+	return err
+}
 
-	table := s.refTable
+// 生成建表语句，表名和列名使用dialect的方式加上引号
+func (s *Session) createTableSQL() string {
+	table := s.RefTable()
 	var columns []string
 	for _, field := range table.Fields {
-		columns = append(columns, fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag))
+		columns = append(columns, strings.TrimSpace(fmt.Sprintf("%s %s %s", s.quote(field.ColumnName), field.Type, field.Tag)))
 	}
 	desc := strings.Join(columns, ",")
-	_, err := s.Raw(fmt.Sprintf("CREATE TABLE %s (%s);", table.Name, desc)).Exec()
-	return err
+	return fmt.Sprintf("CREATE TABLE %s (%s);", s.quote(table.Name), desc)
 }
 
 func (s *Session) DropTable() error {
-	_, err := s.Raw(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.RefTable().Name)).Exec()
+	table := s.RefTable()
+	if table == nil {
+		return ErrModelNotSet
+	}
+	_, err := s.Raw(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.quote(table.Name))).Exec()
 	return err
 }
 
+// 没有设置Model时返回false
 func (s *Session) HasTable() bool {
-	sql, values := s.dialect.TableExistSQL(s.RefTable().Name)
+	table := s.RefTable()
+	if table == nil {
+		return false
+	}
+	sql, values := s.dialect.TableExistSQL(table.Name)
 	row := s.Raw(sql, values...).QueryRow()
 	var tmp string
 	_ = row.Scan(&tmp)
-	return tmp == s.RefTable().Name
+	return tmp == table.Name
 }
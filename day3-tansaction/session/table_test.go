@@ -1,6 +1,11 @@
 package session
 
-import "testing"
+import (
+	"errors"
+	"geeorm/dialect"
+	"testing"
+	"time"
+)
 
 type User struct {
 	Name string `geeorm:"PRIMARY KEY"`
@@ -15,3 +20,84 @@ func TestSession_CreateTable(t *testing.T) {
 		t.Fatal("Failed to create table User")
 	}
 }
+
+func TestSession_ModelNotSet(t *testing.T) {
+	s := NewSession()
+	//Model返回新的会话，忽略返回值时s上没有模型
+	s.Model(&User{})
+	if s.HasTable() {
+		t.Fatal("expect false without model")
+	}
+	if err := s.DropTable(); !errors.Is(err, ErrModelNotSet) {
+		t.Fatal("expect ErrModelNotSet, but got", err)
+	}
+	if err := s.CreateTable(); !errors.Is(err, ErrModelNotSet) {
+		t.Fatal("expect ErrModelNotSet, but got", err)
+	}
+	if _, err := s.Update("Age", 1); !errors.Is(err, ErrModelNotSet) {
+		t.Fatal("expect ErrModelNotSet, but got", err)
+	}
+	if _, err := s.Delete(); !errors.Is(err, ErrModelNotSet) {
+		t.Fatal("expect ErrModelNotSet, but got", err)
+	}
+	if _, err := s.Count(); !errors.Is(err, ErrModelNotSet) {
+		t.Fatal("expect ErrModelNotSet, but got", err)
+	}
+}
+
+type Product struct {
+	ID      uint64 `geeorm:"PRIMARY KEY AUTO_INCREMENT"`
+	Name    string `geeorm:"size:64"`
+	OnSale  bool
+	Created time.Time
+}
+
+func TestSession_CreateTableSQL(t *testing.T) {
+	mysqlDial, _ := dialect.GetDialect("mysql")
+	cases := []struct {
+		Dialect dialect.Dialect
+		SQL     string
+	}{
+		{TestDial, `CREATE TABLE "Product" ("ID" bigint PRIMARY KEY AUTO_INCREMENT,"Name" text,"OnSale" bool,"Created" datetime);`},
+		{mysqlDial, "CREATE TABLE `Product` (`ID` bigint unsigned PRIMARY KEY AUTO_INCREMENT,`Name` varchar(64),`OnSale` tinyint(1),`Created` datetime(6));"},
+	}
+	for _, c := range cases {
+		if sql := New(nil, c.Dialect).Model(&Product{}).createTableSQL(); sql != c.SQL {
+			t.Fatalf("expect %s, but got %s", c.SQL, sql)
+		}
+	}
+}
+
+type Customer struct {
+	ID    int64  `geeorm:"primary key"`
+	Name  string `geeorm:"column:customer_name;size:32;not null"`
+	Level int    `geeorm:"column:level;default:1"`
+	Cache string `geeorm:"-"`
+}
+
+func TestSession_ColumnTags(t *testing.T) {
+	mysqlDial, _ := dialect.GetDialect("mysql")
+	want := "CREATE TABLE `Customer` (`ID` bigint PRIMARY KEY,`customer_name` varchar(32) NOT NULL,`level` bigint DEFAULT 1);"
+	if sql := New(nil, mysqlDial).Model(&Customer{}).createTableSQL(); sql != want {
+		t.Fatalf("expect %s, but got %s", want, sql)
+	}
+
+	s := NewSession().Model(&Customer{})
+	_ = s.DropTable()
+	if err := s.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Insert(&Customer{ID: 1, Name: "Tom", Level: 2, Cache: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Where("customer_name = ?", "Tom").Update("Level", 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateModel(&Customer{ID: 1, Name: "Sam", Level: 3}); err != nil {
+		t.Fatal(err)
+	}
+	var c Customer
+	if err := s.First(&c); err != nil || c != (Customer{ID: 1, Name: "Sam", Level: 3}) {
+		t.Fatal("failed to map columns", c, err)
+	}
+}
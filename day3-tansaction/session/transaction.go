@@ -1,34 +1,223 @@
 package session
 
-import "geeorm/log"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"geeorm/log"
+)
 
-// 用于启动一个数据库事务
+// 事务函数，返回错误或者panic时事务回滚
+type TxFunc func(*Session) (interface{}, error)
+
+// 事务状态，同一事务中通过链式调用克隆出的Session共享同一个txState
+type txState struct {
+	tx     *sql.Tx
+	opts   *sql.TxOptions //最外层事务的选项
+	depth  int            //嵌套事务（保存点）的层数
+	frames []*txFrame     //每一层事务登记的AfterCommit/AfterRollback回调，下标与嵌套层数对应
+}
+
+// 用于启动一个数据库事务，事务绑定到会话的上下文
 func (s *Session) Begin() (err error) {
-	log.Info("transaction begin")
+	return s.BeginTx(s.Context(), nil)
+}
+
+// 使用指定的上下文和事务选项启动事务，opts为nil时使用数据库默认的隔离级别。
+// 会话之后的SQL也使用ctx。Begin、BeginTx、Commit和Rollback会修改会话本身，
+// 不能在多个goroutine共享的会话上调用，这种情况下应使用Transaction，它在会话的副本上开启事务
+func (s *Session) BeginTx(ctx context.Context, opts *sql.TxOptions) (err error) {
+	if s.inTx() {
+		return errors.New("transaction already begun, use Transaction for nested transactions")
+	}
+	//先由dialect检查隔离级别和只读标记，避免驱动静默忽略不支持的选项
+	if opts != nil {
+		if err = s.dialect.CheckTxOptions(opts); err != nil {
+			s.Logger().Error(s.Context(), "invalid transaction options", log.Any(log.FieldError, err))
+			return
+		}
+	}
+	if ctx != nil {
+		s.ctx = ctx
+	}
+	s.Logger().Info(s.Context(), "transaction begin")
 	//判断是否成功启动一个数据库事务
-	if s.tx, err = s.db.Begin(); err != nil {
-		log.Error(err)
+	tx, err := s.db.BeginTx(s.Context(), opts)
+	if err != nil {
+		s.Logger().Error(s.Context(), "transaction begin failed", log.Any(log.FieldError, err))
 		return
 	}
+	s.tx = &txState{tx: tx, opts: opts, frames: []*txFrame{{}}}
 	return
 }
 
-// 判断事务是否提交成功
+// 判断事务是否提交成功，提交成功后调用AfterCommit注册的函数，失败时调用AfterRollback注册的函数
 func (s *Session) Commit() (err error) {
-	log.Info("transaction commit")
-	if err = s.tx.Commit(); err != nil {
-		log.Error(err)
+	s.Logger().Info(s.Context(), "transaction commit")
+	if err = s.tx.tx.Commit(); err != nil {
+		s.Logger().Error(s.Context(), "transaction commit failed", log.Any(log.FieldError, err))
+	}
+	frame := s.endTx()
+	if err != nil {
+		frame.run(frame.afterRollback)
+	} else {
+		frame.run(frame.afterCommit)
 	}
 	return
 }
 
-// 判断事务是否回滚成功
+// 判断事务是否回滚成功，并调用AfterRollback注册的函数
 func (s *Session) Rollback() (err error) {
 
-	log.Info("transaction rollback")
-	if err = s.tx.Rollback(); err != nil {
-		log.Error(err)
+	s.Logger().Info(s.Context(), "transaction rollback")
+	if err = s.tx.tx.Rollback(); err != nil {
+		s.Logger().Error(s.Context(), "transaction rollback failed", log.Any(log.FieldError, err))
 	}
+	frame := s.endTx()
+	frame.run(frame.afterRollback)
 	return
 
 }
+
+// 清理事务状态，返回最外层事务登记的回调。共享该事务的Session也随之结束事务
+func (s *Session) endTx() *txFrame {
+	frame := s.tx.frames[0]
+	s.tx.tx, s.tx.frames = nil, nil
+	s.tx = nil
+	return frame
+}
+
+// 判断会话是否处于事务中
+func (s *Session) inTx() bool {
+	return s.tx != nil && s.tx.tx != nil
+}
+
+// 一层事务（最外层事务或保存点）中登记的回调
+type txFrame struct {
+	afterCommit   []func()
+	afterRollback []func()
+}
+
+func (f *txFrame) run(fns []func()) {
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// AfterCommit 登记一个在事务提交成功后执行的函数，例如发布事件、清理缓存。
+// 在嵌套事务中登记时，保存点释放后合并到外层事务，回滚到保存点时被丢弃。
+// 不在事务中时立即执行
+func (s *Session) AfterCommit(fn func()) {
+	if !s.inTx() {
+		fn()
+		return
+	}
+	frame := s.tx.frames[len(s.tx.frames)-1]
+	frame.afterCommit = append(frame.afterCommit, fn)
+}
+
+// AfterRollback 登记一个在事务回滚后执行的函数。
+// 在嵌套事务中登记时，回滚到保存点时立即执行，保存点释放后合并到外层事务。
+// 不在事务中时被忽略
+func (s *Session) AfterRollback(fn func()) {
+	if !s.inTx() {
+		return
+	}
+	frame := s.tx.frames[len(s.tx.frames)-1]
+	frame.afterRollback = append(frame.afterRollback, fn)
+}
+
+// 返回当前嵌套事务的深度，0表示不在事务中，1表示最外层事务
+func (s *Session) TxDepth() int {
+	if !s.inTx() {
+		return 0
+	}
+	return s.tx.depth + 1
+}
+
+// 在事务中执行f。会话不在事务中时开启新事务；已经在事务中时创建保存点，
+// f出错只回滚到保存点，外层事务不受影响
+func (s *Session) Transaction(f TxFunc) (result interface{}, err error) {
+	return s.TransactionWith(nil, f)
+}
+
+// 与Transaction相同，但使用opts指定隔离级别和只读标记。
+// 嵌套事务无法修改外层事务的选项，opts与外层不一致时返回错误
+func (s *Session) TransactionWith(opts *sql.TxOptions, f TxFunc) (result interface{}, err error) {
+	//DryRun模式不访问数据库，事务和保存点语句都不执行
+	if s.dryRun != nil {
+		return f(s)
+	}
+	if !s.inTx() {
+		//在副本上开启事务，事务状态不会写入可能被其他goroutine共享的原会话
+		s = s.clone()
+		if err = s.BeginTx(s.Context(), opts); err != nil {
+			return nil, err
+		}
+		defer func() {
+			//recover函数：捕获并处理程序中发生的panic异常，只能在defer函数中调用，
+			//通常和panic配合使用，没有发生panic则返回nil，
+			//注意！需要在发生panic的代码块之前定义，否则无法捕获。
+			//另外，recover（）只能捕获当前goroutine中的panic，无法捕捉到其他goroutine的异常
+			if p := recover(); p != nil {
+				_ = s.Rollback()
+				panic(p)
+			} else if err != nil {
+				_ = s.Rollback()
+			} else {
+				err = s.Commit()
+			}
+		}()
+		return f(s)
+	}
+
+	if opts != nil && *opts != s.txOptions() {
+		return nil, errors.New("nested transaction can not change isolation level or read-only mode")
+	}
+	state := s.tx
+	state.depth++
+	name := fmt.Sprintf("sp_%d", state.depth)
+	if err = s.execTx(s.dialect.SavepointSQL(name)); err != nil {
+		state.depth--
+		return nil, err
+	}
+	state.frames = append(state.frames, &txFrame{})
+	defer func() {
+		state.depth--
+		frame := state.frames[len(state.frames)-1]
+		state.frames = state.frames[:len(state.frames)-1]
+		if p := recover(); p != nil {
+			_ = s.execTx(s.dialect.RollbackToSavepointSQL(name))
+			frame.run(frame.afterRollback)
+			panic(p)
+		} else if err != nil {
+			_ = s.execTx(s.dialect.RollbackToSavepointSQL(name))
+			frame.run(frame.afterRollback)
+		} else {
+			err = s.execTx(s.dialect.ReleaseSavepointSQL(name))
+			//保存点中的修改已经属于外层事务，其中登记的回调跟随外层事务提交或回滚
+			parent := state.frames[len(state.frames)-1]
+			parent.afterCommit = append(parent.afterCommit, frame.afterCommit...)
+			parent.afterRollback = append(parent.afterRollback, frame.afterRollback...)
+		}
+	}()
+	return f(s)
+}
+
+// 返回当前事务的选项，未指定时为默认值
+func (s *Session) txOptions() sql.TxOptions {
+	if s.tx.opts == nil {
+		return sql.TxOptions{}
+	}
+	return *s.tx.opts
+}
+
+// 直接在事务上执行控制语句，不影响会话中正在拼接的SQL
+func (s *Session) execTx(sql string) (err error) {
+	s.Logger().Info(s.Context(), "sql", log.Any(log.FieldSQL, sql))
+	if _, err = s.tx.tx.ExecContext(s.Context(), sql); err != nil {
+		s.Logger().Error(s.Context(), "sql failed", log.Any(log.FieldSQL, sql), log.Any(log.FieldError, err))
+	}
+	return
+}
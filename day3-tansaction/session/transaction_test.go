@@ -0,0 +1,136 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSession_NestedTransaction(t *testing.T) {
+	s := NewSession().Model(&User{})
+	_ = s.DropTable()
+	_ = s.CreateTable()
+	_, err := s.Transaction(func(s *Session) (result interface{}, err error) {
+		if _, err = s.Insert(user1); err != nil {
+			return
+		}
+		_, nestedErr := s.Transaction(func(s *Session) (result interface{}, err error) {
+			if s.TxDepth() != 2 {
+				t.Fatal("expect depth 2, but got", s.TxDepth())
+			}
+			_, _ = s.Insert(user2)
+			return nil, errors.New("rollback to savepoint")
+		})
+		if nestedErr == nil || s.TxDepth() != 1 {
+			t.Fatal("expect nested transaction to fail")
+		}
+		_, err = s.Transaction(func(s *Session) (result interface{}, err error) {
+			_, err = s.Insert(user3)
+			return
+		})
+		return
+	})
+	if err != nil || s.TxDepth() != 0 {
+		t.Fatal("failed to commit outer transaction", err)
+	}
+	var users []User
+	if err = s.OrderBy("Name").Find(&users); err != nil || len(users) != 2 ||
+		users[0].Name != "Jack" || users[1].Name != "Tom" {
+		t.Fatal("failed to rollback to savepoint, got", users)
+	}
+}
+
+func TestSession_TransactionOnCopy(t *testing.T) {
+	s := NewSession().Model(&User{})
+	_, err := s.Transaction(func(tx *Session) (interface{}, error) {
+		if tx.TxDepth() != 1 || s.TxDepth() != 0 {
+			t.Fatal("expect transaction not to be installed on the shared session")
+		}
+		return tx.Count()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_BeginTwice(t *testing.T) {
+	s := NewSession()
+	if err := s.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Rollback()
+	if err := s.Begin(); err == nil {
+		t.Fatal("expect error when beginning a transaction twice")
+	}
+}
+
+func TestSession_TransactionWith(t *testing.T) {
+	s := NewSession().Model(&User{})
+	_, err := s.TransactionWith(&sql.TxOptions{ReadOnly: true}, func(s *Session) (interface{}, error) {
+		return nil, nil
+	})
+	if err == nil {
+		t.Fatal("expect sqlite3 to reject read-only transaction")
+	}
+	_, err = s.TransactionWith(&sql.TxOptions{Isolation: sql.LevelSerializable}, func(s *Session) (interface{}, error) {
+		_, err := s.TransactionWith(&sql.TxOptions{Isolation: sql.LevelSerializable}, func(s *Session) (interface{}, error) {
+			return nil, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.TransactionWith(&sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(s *Session) (interface{}, error) {
+			return nil, nil
+		})
+		if err == nil {
+			t.Fatal("expect error when changing isolation level in nested transaction")
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal("failed to run serializable transaction", err)
+	}
+}
+
+func TestSession_AfterCommit(t *testing.T) {
+	var events []string
+	record := func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+	s := NewSession()
+	_, _ = s.Transaction(func(s *Session) (interface{}, error) {
+		s.AfterCommit(record("commit outer"))
+		s.AfterRollback(record("rollback outer"))
+		_, _ = s.Transaction(func(s *Session) (interface{}, error) {
+			s.AfterCommit(record("commit released"))
+			return nil, nil
+		})
+		_, _ = s.Transaction(func(s *Session) (interface{}, error) {
+			s.AfterCommit(record("commit discarded"))
+			s.AfterRollback(record("rollback savepoint"))
+			return nil, errors.New("rollback to savepoint")
+		})
+		if len(events) != 1 || events[0] != "rollback savepoint" {
+			t.Fatal("expect savepoint rollback callbacks to run immediately, got", events)
+		}
+		return nil, nil
+	})
+	expect := []string{"rollback savepoint", "commit outer", "commit released"}
+	if !reflect.DeepEqual(events, expect) {
+		t.Fatal("failed to run commit callbacks, got", events)
+	}
+
+	events = nil
+	_, _ = s.Transaction(func(s *Session) (interface{}, error) {
+		s.AfterCommit(record("commit"))
+		_, _ = s.Transaction(func(s *Session) (interface{}, error) {
+			s.AfterRollback(record("rollback merged"))
+			return nil, nil
+		})
+		return nil, errors.New("rollback")
+	})
+	if !reflect.DeepEqual(events, []string{"rollback merged"}) {
+		t.Fatal("failed to run rollback callbacks, got", events)
+	}
+}